@@ -2,6 +2,8 @@ package fibernetia
 
 import (
 	"context"
+
+	"github.com/valyala/fasthttp"
 )
 
 type contextKey int
@@ -12,11 +14,31 @@ const (
 	validationErrorsContextKey
 	encryptHistoryContextKey
 	clearHistoryContextKey
+	fiberCtxContextKey
+	cookieFlashContextKey
+	storageFlashContextKey
+	flashMessagesContextKey
+	ssrContextKey
+	rootTemplateContextKey
 )
 
+// withValue stores the value in the request-scoped store.
+//
+// If ctx is a *fasthttp.RequestCtx, the value is saved with SetUserValue
+// (the same store that backs Fiber's Locals) and ctx itself is returned,
+// so the value survives until Render even when the result is discarded.
+// Any other context is wrapped with context.WithValue.
+func withValue(ctx context.Context, key contextKey, val any) context.Context {
+	if rc, ok := ctx.(*fasthttp.RequestCtx); ok {
+		rc.SetUserValue(key, val)
+		return rc
+	}
+	return context.WithValue(ctx, key, val)
+}
+
 // SetTemplateData sets template data to the passed context.
 func SetTemplateData(ctx context.Context, templateData TemplateData) context.Context {
-	return context.WithValue(ctx, templateDataContextKey, templateData)
+	return withValue(ctx, templateDataContextKey, templateData)
 }
 
 // SetTemplateDatum sets single template data item to the passed context.
@@ -37,7 +59,7 @@ func TemplateDataFromContext(ctx context.Context) TemplateData {
 
 // SetProps sets props values to the passed context.
 func SetProps(ctx context.Context, props Props) context.Context {
-	return context.WithValue(ctx, propsContextKey, props)
+	return withValue(ctx, propsContextKey, props)
 }
 
 // SetProp sets prop value to the passed context.
@@ -58,7 +80,7 @@ func PropsFromContext(ctx context.Context) Props {
 
 // SetValidationErrors sets validation errors to the passed context.
 func SetValidationErrors(ctx context.Context, errors ValidationErrors) context.Context {
-	return context.WithValue(ctx, validationErrorsContextKey, errors)
+	return withValue(ctx, validationErrorsContextKey, errors)
 }
 
 // AddValidationErrors appends validation errors to the passed context.
@@ -88,7 +110,7 @@ func ValidationErrorsFromContext(ctx context.Context) ValidationErrors {
 
 // SetEncryptHistory enables or disables history encryption.
 func SetEncryptHistory(ctx context.Context, encrypt ...bool) context.Context {
-	return context.WithValue(ctx, encryptHistoryContextKey, firstOr[bool](encrypt, true))
+	return withValue(ctx, encryptHistoryContextKey, firstOr[bool](encrypt, true))
 }
 
 // EncryptHistoryFromContext returns history encryption value from the context.
@@ -99,7 +121,7 @@ func EncryptHistoryFromContext(ctx context.Context) (bool, bool) {
 
 // ClearHistory cleaning history state.
 func ClearHistory(ctx context.Context) context.Context {
-	return context.WithValue(ctx, clearHistoryContextKey, true)
+	return withValue(ctx, clearHistoryContextKey, true)
 }
 
 // ClearHistoryFromContext returns clear history value from the context.
@@ -110,3 +132,54 @@ func ClearHistoryFromContext(ctx context.Context) bool {
 	}
 	return false
 }
+
+// SetSSR enables or disables server side rendering for the request.
+// It has no effect if SSR is not enabled with WithSSR.
+func SetSSR(ctx context.Context, ssr ...bool) context.Context {
+	return withValue(ctx, ssrContextKey, firstOr[bool](ssr, true))
+}
+
+// SSRFromContext returns server side rendering value from the context.
+func SSRFromContext(ctx context.Context) (bool, bool) {
+	ssr, ok := ctx.Value(ssrContextKey).(bool)
+	return ssr, ok
+}
+
+// SetRootTemplate sets the name of the root template to the passed context.
+// An empty name selects the default root template.
+func SetRootTemplate(ctx context.Context, name string) context.Context {
+	return withValue(ctx, rootTemplateContextKey, name)
+}
+
+// RootTemplateFromContext returns the name of the root template from the context.
+func RootTemplateFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(rootTemplateContextKey).(string)
+	return name, ok
+}
+
+// SetFlashMessage sets flash message to the passed context.
+func SetFlashMessage(ctx context.Context, key string, val any) context.Context {
+	flashMessages := FlashFromContext(ctx)
+	flashMessages[key] = val
+	return withValue(ctx, flashMessagesContextKey, flashMessages)
+}
+
+// FlashFromContext returns flash messages from the context.
+func FlashFromContext(ctx context.Context) FlashMessages {
+	flashMessages, ok := ctx.Value(flashMessagesContextKey).(FlashMessages)
+	if ok {
+		return flashMessages
+	}
+	return FlashMessages{}
+}
+
+// requestCtxFromContext returns the underlying *fasthttp.RequestCtx of the context.
+func requestCtxFromContext(ctx context.Context) (*fasthttp.RequestCtx, bool) {
+	if rc, ok := ctx.(*fasthttp.RequestCtx); ok {
+		return rc, true
+	}
+	if c, ok := FiberCtxFromContext(ctx); ok {
+		return c.Context(), true
+	}
+	return nil, false
+}
@@ -0,0 +1,62 @@
+package fibernetia
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// FiberMiddleware returns Inertia middleware as a Fiber handler.
+// Register it with app.Use, group.Use or per route, the same way as Middleware.
+//
+// Errors returned by the next handlers are passed through untouched,
+// so they are processed by Fiber's error handler.
+func (i *Inertia) FiberMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(fiberCtxContextKey, c)
+
+		return i.handle(c.Context(), func(_ *fasthttp.RequestCtx) error {
+			return c.Next()
+		})
+	}
+}
+
+// FiberCtxFromContext returns the *fiber.Ctx stored by FiberMiddleware.
+// It is useful for flash providers and props that need Fiber's API,
+// but only receive the request context.
+func FiberCtxFromContext(ctx context.Context) (*fiber.Ctx, bool) {
+	c, ok := ctx.Value(fiberCtxContextKey).(*fiber.Ctx)
+	return c, ok
+}
+
+// RenderFiber is Render for Fiber handlers.
+func (i *Inertia) RenderFiber(c *fiber.Ctx, component string, props ...Props) error {
+	return i.Render(c.Context(), component, props...)
+}
+
+// RenderFiberWithOptions is RenderWithOptions for Fiber handlers.
+func (i *Inertia) RenderFiberWithOptions(c *fiber.Ctx, component string, props Props, opts ...RenderOption) error {
+	return i.RenderWithOptions(c.Context(), component, props, opts...)
+}
+
+// LocationFiber is Location for Fiber handlers.
+// It always returns nil, so it can be used as a handler's return value.
+func (i *Inertia) LocationFiber(c *fiber.Ctx, url string, status ...int) error {
+	i.Location(c.Context(), url, status...)
+	return nil
+}
+
+// BackFiber is Back for Fiber handlers.
+// It always returns nil, so it can be used as a handler's return value.
+func (i *Inertia) BackFiber(c *fiber.Ctx, status ...int) error {
+	i.Back(c.Context(), status...)
+	return nil
+}
+
+// RedirectFiber is Redirect for Fiber handlers.
+// It always returns nil, so it can be used as a handler's return value.
+func (i *Inertia) RedirectFiber(c *fiber.Ctx, url string, status ...int) error {
+	i.Redirect(c.Context(), url, status...)
+	return nil
+}
@@ -1,69 +1,289 @@
 package fibernetia
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+
 	"github.com/goccy/go-json"
-	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/session"
 )
 
-// SessionFlashProvider implements FlashProvider using github.com/gofiber/session.
+var errFiberCtxNotFound = errors.New("fiber context not found, wrap handlers with FiberMiddleware")
+
+// flashValue is the stored form of a flashed value.
+// Kind keeps the concrete type, so ValidationErrors and FlashMessages are restored
+// as their own types after decoding and not as plain maps.
+type flashValue struct {
+	Kind  string          `json:"kind,omitempty"`
+	Value json.RawMessage `json:"value"`
+}
+
+const (
+	flashKindValidationErrors = "validation_errors"
+	flashKindFlashMessages    = "flash_messages"
+)
+
+func marshalFlashValue(val any) ([]byte, error) {
+	bs, err := json.Marshal(val)
+	if err != nil {
+		return nil, fmt.Errorf("json marshal flash value: %w", err)
+	}
+
+	fv := flashValue{Value: bs}
+	switch val.(type) {
+	case ValidationErrors:
+		fv.Kind = flashKindValidationErrors
+	case FlashMessages:
+		fv.Kind = flashKindFlashMessages
+	}
+
+	return json.Marshal(fv)
+}
+
+func unmarshalFlashValue(data []byte) (any, error) {
+	var fv flashValue
+	if err := json.Unmarshal(data, &fv); err != nil {
+		return nil, fmt.Errorf("json unmarshal flash value: %w", err)
+	}
+
+	switch fv.Kind {
+	case flashKindValidationErrors:
+		var validationErrors ValidationErrors
+		if err := json.Unmarshal(fv.Value, &validationErrors); err != nil {
+			return nil, fmt.Errorf("json unmarshal validation errors: %w", err)
+		}
+		return validationErrors, nil
+	case flashKindFlashMessages:
+		var flashMessages FlashMessages
+		if err := json.Unmarshal(fv.Value, &flashMessages); err != nil {
+			return nil, fmt.Errorf("json unmarshal flash messages: %w", err)
+		}
+		return flashMessages, nil
+	default:
+		var v any
+		if err := json.Unmarshal(fv.Value, &v); err != nil {
+			return nil, fmt.Errorf("json unmarshal flash value: %w", err)
+		}
+		return v, nil
+	}
+}
+
+// flashData is the flash data of a single visitor, stored by the cookie and storage providers.
+type flashData struct {
+	Values       map[string]json.RawMessage `json:"v,omitempty"`
+	ClearHistory bool                       `json:"h,omitempty"`
+}
+
+// flashState is the request-scoped flash state.
+// Incoming data was flashed by the previous request, outgoing data is flashed by the current one.
+type flashState struct {
+	mu       sync.Mutex
+	incoming flashData
+	outgoing flashData
+}
+
+// clone returns a copy of the data, which can be modified without changing the original.
+func (d flashData) clone() flashData {
+	d.Values = maps.Clone(d.Values)
+	if d.Values == nil {
+		d.Values = make(map[string]json.RawMessage)
+	}
+	return d
+}
+
+// flash adds the value to the outgoing data and persists it.
+// If the data cannot be persisted (e.g. it is too large), the outgoing data is left unchanged.
+func (s *flashState) flash(key string, data []byte, persist func(data flashData) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	outgoing := s.outgoing.clone()
+	outgoing.Values[key] = data
+
+	return s.persist(outgoing, persist)
+}
+
+// flashClearHistory sets the clear history flag in the outgoing data and persists it.
+// If the data cannot be persisted, the outgoing data is left unchanged.
+func (s *flashState) flashClearHistory(persist func(data flashData) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	outgoing := s.outgoing.clone()
+	outgoing.ClearHistory = true
+
+	return s.persist(outgoing, persist)
+}
+
+// reflash moves the unread incoming data to the outgoing data and persists it.
+// If the data cannot be persisted, both the incoming and the outgoing data are left unchanged.
+func (s *flashState) reflash(persist func(data flashData) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.incoming.Values) == 0 && !s.incoming.ClearHistory {
+		return nil
+	}
+
+	outgoing := s.outgoing.clone()
+	for key, data := range s.incoming.Values {
+		if _, ok := outgoing.Values[key]; !ok {
+			outgoing.Values[key] = data
+		}
+	}
+	outgoing.ClearHistory = outgoing.ClearHistory || s.incoming.ClearHistory
+
+	if err := s.persist(outgoing, persist); err != nil {
+		return err
+	}
+	s.incoming = flashData{}
+
+	return nil
+}
+
+// persist persists the outgoing data and keeps it only on success. It must be called with the lock held.
+func (s *flashState) persist(outgoing flashData, persist func(data flashData) error) error {
+	if err := persist(outgoing); err != nil {
+		return err
+	}
+	s.outgoing = outgoing
+
+	return nil
+}
+
+// get returns the incoming value and removes it, so it can be read only once.
+func (s *flashState) get(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, ok := s.incoming.Values[key]
+	delete(s.incoming.Values, key)
+
+	return data, ok
+}
+
+// shouldClearHistory returns the incoming clear history flag and resets it.
+func (s *flashState) shouldClearHistory() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clearHistory := s.incoming.ClearHistory
+	s.incoming.ClearHistory = false
+
+	return clearHistory
+}
+
+const (
+	sessionFlashKeyPrefix       = "flash_"
+	sessionFlashClearHistoryKey = "flash_clear_history"
+)
+
+// SessionFlashProvider implements FlashProvider using Fiber's session middleware.
+//
+// The session is looked up from the *fiber.Ctx stored by FiberMiddleware,
+// so handlers must be wrapped with it.
 type SessionFlashProvider struct {
 	store *session.Store
 }
 
+var _ FlashProvider = (*SessionFlashProvider)(nil)
+
+// NewSessionFlashProvider returns SessionFlashProvider backed by the session store.
 func NewSessionFlashProvider(store *session.Store) *SessionFlashProvider {
 	return &SessionFlashProvider{store: store}
 }
 
-func (p *SessionFlashProvider) Flash(c *fiber.Ctx, key string, val any) error {
+func (p *SessionFlashProvider) session(ctx context.Context) (*session.Session, error) {
+	c, ok := FiberCtxFromContext(ctx)
+	if !ok {
+		return nil, errFiberCtxNotFound
+	}
+
 	sess, err := p.store.Get(c)
+	if err != nil {
+		return nil, fmt.Errorf("get session: %w", err)
+	}
+
+	return sess, nil
+}
+
+// Flash stores the value in the session until it is read.
+func (p *SessionFlashProvider) Flash(ctx context.Context, key string, val any) error {
+	sess, err := p.session(ctx)
 	if err != nil {
 		return err
 	}
-	data, err := json.Marshal(val)
+
+	data, err := marshalFlashValue(val)
 	if err != nil {
 		return err
 	}
-	sess.Set("flash_"+key, data)
+
+	sess.Set(sessionFlashKeyPrefix+key, data)
+
+	if err = sess.Save(); err != nil {
+		return fmt.Errorf("save session: %w", err)
+	}
+
 	return nil
 }
 
-func (p *SessionFlashProvider) Get(c *fiber.Ctx, key string) (any, error) {
-	sess, err := p.store.Get(c)
+// Get returns the flashed value and removes it from the session.
+func (p *SessionFlashProvider) Get(ctx context.Context, key string) (any, error) {
+	sess, err := p.session(ctx)
 	if err != nil {
 		return nil, err
 	}
-	val := sess.Get("flash_" + key)
-	if val == nil {
+
+	data, ok := sess.Get(sessionFlashKeyPrefix + key).([]byte)
+	if !ok {
 		return nil, nil
 	}
-	var v any
-	err = json.Unmarshal(val.([]byte), &v)
-	if err != nil {
-		return nil, err
+
+	sess.Delete(sessionFlashKeyPrefix + key)
+
+	if err = sess.Save(); err != nil {
+		return nil, fmt.Errorf("save session: %w", err)
 	}
-	sess.Delete("flash_" + key) // delete after read
-	return v, nil
+
+	return unmarshalFlashValue(data)
 }
 
-func (p *SessionFlashProvider) FlashClearHistory(c *fiber.Ctx) error {
-	sess, err := p.store.Get(c)
+// FlashClearHistory stores the clear history flag in the session until it is read.
+func (p *SessionFlashProvider) FlashClearHistory(ctx context.Context) error {
+	sess, err := p.session(ctx)
 	if err != nil {
 		return err
 	}
-	sess.Set("flash_clear_history", true)
+
+	sess.Set(sessionFlashClearHistoryKey, true)
+
+	if err = sess.Save(); err != nil {
+		return fmt.Errorf("save session: %w", err)
+	}
+
 	return nil
 }
 
-func (p *SessionFlashProvider) ShouldClearHistory(c *fiber.Ctx) (bool, error) {
-	sess, err := p.store.Get(c)
+// ShouldClearHistory returns the clear history flag and removes it from the session.
+func (p *SessionFlashProvider) ShouldClearHistory(ctx context.Context) (bool, error) {
+	sess, err := p.session(ctx)
 	if err != nil {
 		return false, err
 	}
-	val := sess.Get("flash_clear_history")
-	if val == nil {
+
+	if sess.Get(sessionFlashClearHistoryKey) == nil {
 		return false, nil
 	}
-	sess.Delete("flash_clear_history") // delete after read
+
+	sess.Delete(sessionFlashClearHistoryKey)
+
+	if err = sess.Save(); err != nil {
+		return false, fmt.Errorf("save session: %w", err)
+	}
+
 	return true, nil
 }
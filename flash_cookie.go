@@ -0,0 +1,397 @@
+package fibernetia
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/valyala/fasthttp"
+)
+
+// ErrFlashCookieTooLarge is returned when the encoded flash cookie
+// exceeds the maximum cookie size.
+var ErrFlashCookieTooLarge = errors.New("flash cookie is too large")
+
+var errRequestCtxNotFound = errors.New("fasthttp request context not found")
+
+const (
+	defaultFlashCookieName    = "inertia_flash"
+	defaultFlashCookieMaxSize = 4096
+	minFlashCookieKeyLen      = 32
+)
+
+// CookieFlashProvider implements FlashProvider without any server-side store.
+// Flash data is kept in an HMAC-signed (and optionally AES-GCM encrypted) cookie,
+// which is consumed and cleared on the next request.
+type CookieFlashProvider struct {
+	keys     [][]byte
+	encrypt  bool
+	name     string
+	path     string
+	domain   string
+	secure   bool
+	sameSite fasthttp.CookieSameSite
+	maxSize  int
+}
+
+var _ FlashProvider = (*CookieFlashProvider)(nil)
+var _ FlashReflasher = (*CookieFlashProvider)(nil)
+
+// CookieFlashOption is an option parameter that modifies CookieFlashProvider.
+type CookieFlashOption func(p *CookieFlashProvider) error
+
+// NewCookieFlashProvider returns CookieFlashProvider that signs cookies with the passed key.
+// The key must be at least 32 bytes long.
+func NewCookieFlashProvider(key []byte, opts ...CookieFlashOption) (*CookieFlashProvider, error) {
+	if len(key) < minFlashCookieKeyLen {
+		return nil, fmt.Errorf("flash cookie key must be at least %d bytes", minFlashCookieKeyLen)
+	}
+
+	p := &CookieFlashProvider{
+		keys:     [][]byte{key},
+		name:     defaultFlashCookieName,
+		path:     "/",
+		sameSite: fasthttp.CookieSameSiteLaxMode,
+		maxSize:  defaultFlashCookieMaxSize,
+	}
+
+	for _, opt := range opts {
+		if err := opt(p); err != nil {
+			return nil, fmt.Errorf("initialize cookie flash provider: %w", err)
+		}
+	}
+
+	return p, nil
+}
+
+// WithFlashCookieOldKeys returns CookieFlashOption that adds previous keys.
+// They are only used to verify and decrypt cookies, which allows key rotation
+// without losing flash data that is in flight.
+func WithFlashCookieOldKeys(keys ...[]byte) CookieFlashOption {
+	return func(p *CookieFlashProvider) error {
+		for _, key := range keys {
+			if len(key) < minFlashCookieKeyLen {
+				return fmt.Errorf("flash cookie key must be at least %d bytes", minFlashCookieKeyLen)
+			}
+			p.keys = append(p.keys, key)
+		}
+		return nil
+	}
+}
+
+// WithFlashCookieEncryption returns CookieFlashOption that enables AES-GCM encryption of the cookie.
+func WithFlashCookieEncryption(encrypt ...bool) CookieFlashOption {
+	return func(p *CookieFlashProvider) error {
+		p.encrypt = firstOr[bool](encrypt, true)
+		return nil
+	}
+}
+
+// WithFlashCookieName returns CookieFlashOption that will set the cookie name.
+func WithFlashCookieName(name string) CookieFlashOption {
+	return func(p *CookieFlashProvider) error {
+		if name == "" {
+			return fmt.Errorf("blank flash cookie name")
+		}
+		p.name = name
+		return nil
+	}
+}
+
+// WithFlashCookiePath returns CookieFlashOption that will set the cookie path.
+func WithFlashCookiePath(path string) CookieFlashOption {
+	return func(p *CookieFlashProvider) error {
+		p.path = path
+		return nil
+	}
+}
+
+// WithFlashCookieDomain returns CookieFlashOption that will set the cookie domain.
+func WithFlashCookieDomain(domain string) CookieFlashOption {
+	return func(p *CookieFlashProvider) error {
+		p.domain = domain
+		return nil
+	}
+}
+
+// WithFlashCookieSecure returns CookieFlashOption that will set the cookie secure flag.
+func WithFlashCookieSecure(secure ...bool) CookieFlashOption {
+	return func(p *CookieFlashProvider) error {
+		p.secure = firstOr[bool](secure, true)
+		return nil
+	}
+}
+
+// WithFlashCookieSameSite returns CookieFlashOption that will set the cookie SameSite mode.
+func WithFlashCookieSameSite(sameSite fasthttp.CookieSameSite) CookieFlashOption {
+	return func(p *CookieFlashProvider) error {
+		p.sameSite = sameSite
+		return nil
+	}
+}
+
+// WithFlashCookieMaxSize returns CookieFlashOption that will set the maximum encoded cookie size.
+func WithFlashCookieMaxSize(size int) CookieFlashOption {
+	return func(p *CookieFlashProvider) error {
+		if size <= 0 {
+			return fmt.Errorf("invalid flash cookie max size: %d", size)
+		}
+		p.maxSize = size
+		return nil
+	}
+}
+
+// Flash stores the value in the response cookie.
+func (p *CookieFlashProvider) Flash(ctx context.Context, key string, val any) error {
+	rc, state, err := p.state(ctx)
+	if err != nil {
+		return err
+	}
+
+	data, err := marshalFlashValue(val)
+	if err != nil {
+		return err
+	}
+
+	return state.flash(key, data, func(data flashData) error {
+		return p.writeCookie(rc, data)
+	})
+}
+
+// Get returns the value flashed by the previous request.
+func (p *CookieFlashProvider) Get(ctx context.Context, key string) (any, error) {
+	_, state, err := p.state(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := state.get(key)
+	if !ok {
+		return nil, nil
+	}
+
+	return unmarshalFlashValue(data)
+}
+
+// FlashClearHistory stores the clear history flag in the response cookie.
+func (p *CookieFlashProvider) FlashClearHistory(ctx context.Context) error {
+	rc, state, err := p.state(ctx)
+	if err != nil {
+		return err
+	}
+
+	return state.flashClearHistory(func(data flashData) error {
+		return p.writeCookie(rc, data)
+	})
+}
+
+// ShouldClearHistory returns the clear history flag flashed by the previous request.
+func (p *CookieFlashProvider) ShouldClearHistory(ctx context.Context) (bool, error) {
+	_, state, err := p.state(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return state.shouldClearHistory(), nil
+}
+
+// Reflash keeps the unread flash data of the previous request for the next one.
+func (p *CookieFlashProvider) Reflash(ctx context.Context) error {
+	rc, state, err := p.state(ctx)
+	if err != nil {
+		return err
+	}
+
+	return state.reflash(func(data flashData) error {
+		return p.writeCookie(rc, data)
+	})
+}
+
+// state returns the request-scoped flash state, reading the request cookie on first access.
+// The cookie is consumed: it is cleared in the response, unless new data is flashed.
+func (p *CookieFlashProvider) state(ctx context.Context) (*fasthttp.RequestCtx, *flashState, error) {
+	rc, ok := requestCtxFromContext(ctx)
+	if !ok {
+		return nil, nil, errRequestCtxNotFound
+	}
+
+	if state, ok := rc.UserValue(cookieFlashContextKey).(*flashState); ok {
+		return rc, state, nil
+	}
+
+	state := &flashState{}
+	rc.SetUserValue(cookieFlashContextKey, state)
+
+	value := rc.Request.Header.Cookie(p.name)
+	if len(value) == 0 {
+		return rc, state, nil
+	}
+
+	p.expireCookie(rc)
+
+	if err := p.decode(string(value), &state.incoming); err != nil {
+		return rc, state, fmt.Errorf("decode flash cookie: %w", err)
+	}
+
+	return rc, state, nil
+}
+
+func (p *CookieFlashProvider) writeCookie(rc *fasthttp.RequestCtx, data flashData) error {
+	value, err := p.encode(data)
+	if err != nil {
+		return fmt.Errorf("encode flash cookie: %w", err)
+	}
+
+	if len(p.name)+len(value) > p.maxSize {
+		return fmt.Errorf("%w: %d bytes, max %d bytes", ErrFlashCookieTooLarge, len(p.name)+len(value), p.maxSize)
+	}
+
+	cookie := p.acquireCookie()
+	defer fasthttp.ReleaseCookie(cookie)
+
+	cookie.SetValue(value)
+	rc.Response.Header.SetCookie(cookie)
+
+	return nil
+}
+
+func (p *CookieFlashProvider) expireCookie(rc *fasthttp.RequestCtx) {
+	cookie := p.acquireCookie()
+	defer fasthttp.ReleaseCookie(cookie)
+
+	cookie.SetMaxAge(-1)
+	cookie.SetExpire(fasthttp.CookieExpireDelete)
+	rc.Response.Header.SetCookie(cookie)
+}
+
+func (p *CookieFlashProvider) acquireCookie() *fasthttp.Cookie {
+	cookie := fasthttp.AcquireCookie()
+	cookie.SetKey(p.name)
+	cookie.SetPath(p.path)
+	cookie.SetDomain(p.domain)
+	cookie.SetSecure(p.secure)
+	cookie.SetHTTPOnly(true)
+	cookie.SetSameSite(p.sameSite)
+	return cookie
+}
+
+// encode returns "payload.signature", where payload is the (optionally encrypted) JSON data
+// and signature is the HMAC-SHA256 of the cookie name and payload, both base64url encoded.
+func (p *CookieFlashProvider) encode(data flashData) (string, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("json marshal: %w", err)
+	}
+
+	key := p.keys[0]
+
+	if p.encrypt {
+		payload, err = encryptFlashCookie(key, payload)
+		if err != nil {
+			return "", fmt.Errorf("encrypt: %w", err)
+		}
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	signature := base64.RawURLEncoding.EncodeToString(p.sign(key, encoded))
+
+	return encoded + "." + signature, nil
+}
+
+// decode verifies and decodes the cookie value, trying every configured key.
+func (p *CookieFlashProvider) decode(value string, data *flashData) error {
+	encoded, encodedSignature, ok := strings.Cut(value, ".")
+	if !ok {
+		return fmt.Errorf("malformed cookie value")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return fmt.Errorf("decode signature: %w", err)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+
+	for _, key := range p.keys {
+		if !hmac.Equal(signature, p.sign(key, encoded)) {
+			continue
+		}
+
+		if p.encrypt {
+			payload, err = decryptFlashCookie(key, payload)
+			if err != nil {
+				return fmt.Errorf("decrypt: %w", err)
+			}
+		}
+
+		if err = json.Unmarshal(payload, data); err != nil {
+			return fmt.Errorf("json unmarshal: %w", err)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("invalid signature")
+}
+
+func (p *CookieFlashProvider) sign(key []byte, encoded string) []byte {
+	mac := hmac.New(sha256.New, deriveFlashCookieKey(key, "sign"))
+	mac.Write([]byte(p.name))
+	mac.Write([]byte{0})
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
+
+func encryptFlashCookie(key, plaintext []byte) ([]byte, error) {
+	gcm, err := flashCookieGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func decryptFlashCookie(key, ciphertext []byte) ([]byte, error) {
+	gcm, err := flashCookieGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, fmt.Errorf("ciphertext is too short")
+	}
+
+	nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func flashCookieGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(deriveFlashCookieKey(key, "encrypt"))
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// deriveFlashCookieKey derives separate 32 byte keys for signing and encryption from the same secret.
+func deriveFlashCookieKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
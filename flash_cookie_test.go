@@ -0,0 +1,186 @@
+package fibernetia
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+var (
+	testFlashCookieKey    = bytes.Repeat([]byte("k"), 32)
+	testFlashCookieOldKey = bytes.Repeat([]byte("o"), 32)
+)
+
+// nextFlashCookieRequest returns a request, which carries the flash cookie set by the previous response.
+func nextFlashCookieRequest(t *testing.T, name string, prev *fasthttp.RequestCtx) *fasthttp.RequestCtx {
+	t.Helper()
+
+	ctx := &fasthttp.RequestCtx{}
+
+	cookie := fasthttp.AcquireCookie()
+	defer fasthttp.ReleaseCookie(cookie)
+
+	cookie.SetKey(name)
+	if prev.Response.Header.Cookie(cookie) {
+		ctx.Request.Header.SetCookieBytesKV(cookie.Key(), cookie.Value())
+	}
+
+	return ctx
+}
+
+func TestCookieFlashProvider_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opts []CookieFlashOption
+	}{
+		{"signed", nil},
+		{"encrypted", []CookieFlashOption{WithFlashCookieEncryption()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p, err := NewCookieFlashProvider(testFlashCookieKey, tt.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			ctx := &fasthttp.RequestCtx{}
+			if err = p.Flash(ctx, "success", "saved"); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err = p.Flash(ctx, "errors", ValidationErrors{"name": "required"}); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err = p.FlashClearHistory(ctx); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			value := string(ctx.Response.Header.PeekCookie(defaultFlashCookieName))
+			if tt.name == "encrypted" && strings.Contains(value, "saved") {
+				t.Fatalf("encrypted cookie contains plaintext: %s", value)
+			}
+
+			next := nextFlashCookieRequest(t, defaultFlashCookieName, ctx)
+
+			got, err := p.Get(next, "success")
+			if err != nil || got != "saved" {
+				t.Fatalf("got %v, %v, want %q", got, err, "saved")
+			}
+
+			got, err = p.Get(next, "errors")
+			if ve, ok := got.(ValidationErrors); err != nil || !ok || ve["name"] != "required" {
+				t.Fatalf("got %#v, %v, want validation errors", got, err)
+			}
+
+			clearHistory, err := p.ShouldClearHistory(next)
+			if err != nil || !clearHistory {
+				t.Fatalf("got %v, %v, want clear history", clearHistory, err)
+			}
+
+			// Values are read only once.
+			if got, _ = p.Get(next, "success"); got != nil {
+				t.Fatalf("got %v on second read, want nil", got)
+			}
+		})
+	}
+}
+
+func TestCookieFlashProvider_Tampered(t *testing.T) {
+	t.Parallel()
+
+	p, err := NewCookieFlashProvider(testFlashCookieKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ctx := &fasthttp.RequestCtx{}
+	if err = p.Flash(ctx, "success", "saved"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	next := nextFlashCookieRequest(t, defaultFlashCookieName, ctx)
+	value := next.Request.Header.Cookie(defaultFlashCookieName)
+	next.Request.Header.SetCookie(defaultFlashCookieName, "x"+string(value))
+
+	if _, err = p.Get(next, "success"); err == nil {
+		t.Fatal("expected error for tampered cookie")
+	}
+}
+
+func TestCookieFlashProvider_KeyRotation(t *testing.T) {
+	t.Parallel()
+
+	for _, encrypt := range []bool{false, true} {
+		oldProvider, err := NewCookieFlashProvider(testFlashCookieOldKey, WithFlashCookieEncryption(encrypt))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		ctx := &fasthttp.RequestCtx{}
+		if err = oldProvider.Flash(ctx, "success", "saved"); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		rotated, err := NewCookieFlashProvider(testFlashCookieKey,
+			WithFlashCookieEncryption(encrypt),
+			WithFlashCookieOldKeys(testFlashCookieOldKey),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		got, err := rotated.Get(nextFlashCookieRequest(t, defaultFlashCookieName, ctx), "success")
+		if err != nil || got != "saved" {
+			t.Fatalf("encrypt=%v: got %v, %v, want %q", encrypt, got, err, "saved")
+		}
+
+		withoutOldKey, err := NewCookieFlashProvider(testFlashCookieKey, WithFlashCookieEncryption(encrypt))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if _, err = withoutOldKey.Get(nextFlashCookieRequest(t, defaultFlashCookieName, ctx), "success"); err == nil {
+			t.Fatalf("encrypt=%v: expected error without the old key", encrypt)
+		}
+	}
+}
+
+func TestCookieFlashProvider_TooLarge(t *testing.T) {
+	t.Parallel()
+
+	p, err := NewCookieFlashProvider(testFlashCookieKey, WithFlashCookieMaxSize(512))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ctx := &fasthttp.RequestCtx{}
+
+	err = p.Flash(ctx, "big", strings.Repeat("x", 1024))
+	if !errors.Is(err, ErrFlashCookieTooLarge) {
+		t.Fatalf("got %v, want ErrFlashCookieTooLarge", err)
+	}
+
+	// The oversized value is not stored, so later flashes of the request still fit.
+	if err = p.Flash(ctx, "success", "saved"); err != nil {
+		t.Fatalf("unexpected error after oversized flash: %s", err)
+	}
+	if err = p.FlashClearHistory(ctx); err != nil {
+		t.Fatalf("unexpected error after oversized flash: %s", err)
+	}
+
+	next := nextFlashCookieRequest(t, defaultFlashCookieName, ctx)
+
+	if got, _ := p.Get(next, "big"); got != nil {
+		t.Fatalf("got %v, want oversized value not to be stored", got)
+	}
+	if got, err := p.Get(next, "success"); err != nil || got != "saved" {
+		t.Fatalf("got %v, %v, want %q", got, err, "saved")
+	}
+}
@@ -0,0 +1,406 @@
+package fibernetia
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	defaultStorageFlashCookieName = "inertia_flash_id"
+	defaultStorageFlashKeyPrefix  = "inertia_flash:"
+	defaultStorageFlashTTL        = 5 * time.Minute
+)
+
+// StorageFlashProvider implements FlashProvider on top of fiber.Storage
+// (memory, Redis, SQLite, etc.). Flash data is keyed by a per-visitor id,
+// which is kept in a cookie. Unread flash data expires after the TTL.
+//
+// Flash data is consumed with a single GetAndDelete call if the storage implements
+// FlashStorageGetDeleter. Otherwise it is read with Get and removed with Delete, which is
+// not atomic: concurrent requests of the same visitor may both read the same flash data.
+type StorageFlashProvider struct {
+	storage    fiber.Storage
+	ttl        time.Duration
+	keyPrefix  string
+	cookieName string
+	secure     bool
+}
+
+// FlashStorageGetDeleter is an optional interface of fiber.Storage, which atomically
+// returns and removes the value of the key, e.g. with Redis GETDEL.
+type FlashStorageGetDeleter interface {
+	// GetAndDelete returns the value of the key, or nil if it does not exist, and removes the key.
+	GetAndDelete(key string) ([]byte, error)
+}
+
+var _ FlashProvider = (*StorageFlashProvider)(nil)
+var _ FlashReflasher = (*StorageFlashProvider)(nil)
+
+// StorageFlashOption is an option parameter that modifies StorageFlashProvider.
+type StorageFlashOption func(p *StorageFlashProvider) error
+
+// NewStorageFlashProvider returns StorageFlashProvider backed by the storage.
+func NewStorageFlashProvider(storage fiber.Storage, opts ...StorageFlashOption) (*StorageFlashProvider, error) {
+	if storage == nil {
+		return nil, fmt.Errorf("nil flash storage")
+	}
+
+	p := &StorageFlashProvider{
+		storage:    storage,
+		ttl:        defaultStorageFlashTTL,
+		keyPrefix:  defaultStorageFlashKeyPrefix,
+		cookieName: defaultStorageFlashCookieName,
+	}
+
+	for _, opt := range opts {
+		if err := opt(p); err != nil {
+			return nil, fmt.Errorf("initialize storage flash provider: %w", err)
+		}
+	}
+
+	return p, nil
+}
+
+// NewMemoryFlashProvider returns StorageFlashProvider backed by in-memory storage.
+// It is intended for tests and single instance deployments.
+func NewMemoryFlashProvider(opts ...StorageFlashOption) (*StorageFlashProvider, error) {
+	return NewStorageFlashProvider(NewMemoryFlashStorage(), opts...)
+}
+
+// WithStorageFlashTTL returns StorageFlashOption that will set the time to live of unread flash data.
+func WithStorageFlashTTL(ttl time.Duration) StorageFlashOption {
+	return func(p *StorageFlashProvider) error {
+		if ttl <= 0 {
+			return fmt.Errorf("invalid flash ttl: %s", ttl)
+		}
+		p.ttl = ttl
+		return nil
+	}
+}
+
+// WithStorageFlashKeyPrefix returns StorageFlashOption that will set the prefix of storage keys.
+func WithStorageFlashKeyPrefix(prefix string) StorageFlashOption {
+	return func(p *StorageFlashProvider) error {
+		p.keyPrefix = prefix
+		return nil
+	}
+}
+
+// WithStorageFlashCookieName returns StorageFlashOption that will set the name of the visitor id cookie.
+func WithStorageFlashCookieName(name string) StorageFlashOption {
+	return func(p *StorageFlashProvider) error {
+		if name == "" {
+			return fmt.Errorf("blank flash cookie name")
+		}
+		p.cookieName = name
+		return nil
+	}
+}
+
+// WithStorageFlashCookieSecure returns StorageFlashOption that will set the visitor id cookie secure flag.
+func WithStorageFlashCookieSecure(secure ...bool) StorageFlashOption {
+	return func(p *StorageFlashProvider) error {
+		p.secure = firstOr[bool](secure, true)
+		return nil
+	}
+}
+
+// Flash stores the value in the storage until it is read or expired.
+func (p *StorageFlashProvider) Flash(ctx context.Context, key string, val any) error {
+	rc, state, err := p.state(ctx)
+	if err != nil {
+		return err
+	}
+
+	data, err := marshalFlashValue(val)
+	if err != nil {
+		return err
+	}
+
+	return state.flash(key, data, func(data flashData) error {
+		return p.save(rc, data)
+	})
+}
+
+// Get returns the value flashed by the previous request.
+func (p *StorageFlashProvider) Get(ctx context.Context, key string) (any, error) {
+	_, state, err := p.state(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := state.get(key)
+	if !ok {
+		return nil, nil
+	}
+
+	return unmarshalFlashValue(data)
+}
+
+// FlashClearHistory stores the clear history flag in the storage until it is read or expired.
+func (p *StorageFlashProvider) FlashClearHistory(ctx context.Context) error {
+	rc, state, err := p.state(ctx)
+	if err != nil {
+		return err
+	}
+
+	return state.flashClearHistory(func(data flashData) error {
+		return p.save(rc, data)
+	})
+}
+
+// ShouldClearHistory returns the clear history flag flashed by the previous request.
+func (p *StorageFlashProvider) ShouldClearHistory(ctx context.Context) (bool, error) {
+	_, state, err := p.state(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return state.shouldClearHistory(), nil
+}
+
+// Reflash keeps the unread flash data of the previous request for the next one.
+func (p *StorageFlashProvider) Reflash(ctx context.Context) error {
+	rc, state, err := p.state(ctx)
+	if err != nil {
+		return err
+	}
+
+	return state.reflash(func(data flashData) error {
+		return p.save(rc, data)
+	})
+}
+
+// state returns the request-scoped flash state, loading the visitor's data on first access.
+// Loaded data is deleted from the storage, so it is consumed by a single request.
+func (p *StorageFlashProvider) state(ctx context.Context) (*fasthttp.RequestCtx, *flashState, error) {
+	rc, ok := requestCtxFromContext(ctx)
+	if !ok {
+		return nil, nil, errRequestCtxNotFound
+	}
+
+	if state, ok := rc.UserValue(storageFlashContextKey).(*flashState); ok {
+		return rc, state, nil
+	}
+
+	state := &flashState{}
+	rc.SetUserValue(storageFlashContextKey, state)
+
+	id := string(rc.Request.Header.Cookie(p.cookieName))
+	if id == "" {
+		return rc, state, nil
+	}
+
+	bs, err := p.take(p.keyPrefix + id)
+	if err != nil {
+		return rc, state, err
+	}
+	if bs == nil {
+		return rc, state, nil
+	}
+
+	if err = json.Unmarshal(bs, &state.incoming); err != nil {
+		return rc, state, fmt.Errorf("json unmarshal flash data: %w", err)
+	}
+
+	return rc, state, nil
+}
+
+// take returns and removes the flash data of the key.
+func (p *StorageFlashProvider) take(key string) ([]byte, error) {
+	if storage, ok := p.storage.(FlashStorageGetDeleter); ok {
+		bs, err := storage.GetAndDelete(key)
+		if err != nil {
+			return nil, fmt.Errorf("get and delete flash data from storage: %w", err)
+		}
+		return bs, nil
+	}
+
+	bs, err := p.storage.Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("get flash data from storage: %w", err)
+	}
+	if bs == nil {
+		return nil, nil
+	}
+
+	if err = p.storage.Delete(key); err != nil {
+		return nil, fmt.Errorf("delete flash data from storage: %w", err)
+	}
+
+	return bs, nil
+}
+
+func (p *StorageFlashProvider) save(rc *fasthttp.RequestCtx, data flashData) error {
+	id, err := p.visitorID(rc)
+	if err != nil {
+		return err
+	}
+
+	bs, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("json marshal flash data: %w", err)
+	}
+
+	if err = p.storage.Set(p.keyPrefix+id, bs, p.ttl); err != nil {
+		return fmt.Errorf("set flash data to storage: %w", err)
+	}
+
+	return nil
+}
+
+// visitorID returns the visitor id from the request cookie or generates a new one.
+// New ids are sent to the client and remembered for the rest of the request.
+func (p *StorageFlashProvider) visitorID(rc *fasthttp.RequestCtx) (string, error) {
+	if id := rc.Request.Header.Cookie(p.cookieName); len(id) > 0 {
+		return string(id), nil
+	}
+
+	bs := make([]byte, 16)
+	if _, err := rand.Read(bs); err != nil {
+		return "", fmt.Errorf("generate flash visitor id: %w", err)
+	}
+	id := hex.EncodeToString(bs)
+
+	cookie := fasthttp.AcquireCookie()
+	defer fasthttp.ReleaseCookie(cookie)
+
+	cookie.SetKey(p.cookieName)
+	cookie.SetValue(id)
+	cookie.SetPath("/")
+	cookie.SetHTTPOnly(true)
+	cookie.SetSecure(p.secure)
+	cookie.SetSameSite(fasthttp.CookieSameSiteLaxMode)
+	rc.Response.Header.SetCookie(cookie)
+	rc.Request.Header.SetCookie(p.cookieName, id)
+
+	return id, nil
+}
+
+// MemoryFlashStorage is an in-memory fiber.Storage with expiration support.
+// Expired entries are removed lazily on access and periodically on writes.
+type MemoryFlashStorage struct {
+	mu        sync.Mutex
+	entries   map[string]memoryFlashEntry
+	lastSweep time.Time
+}
+
+type memoryFlashEntry struct {
+	val       []byte
+	expiresAt time.Time
+}
+
+func (e memoryFlashEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+var _ fiber.Storage = (*MemoryFlashStorage)(nil)
+var _ FlashStorageGetDeleter = (*MemoryFlashStorage)(nil)
+
+const memoryFlashStorageSweepInterval = time.Minute
+
+// NewMemoryFlashStorage returns empty MemoryFlashStorage.
+func NewMemoryFlashStorage() *MemoryFlashStorage {
+	return &MemoryFlashStorage{entries: make(map[string]memoryFlashEntry)}
+}
+
+// Get returns the value of the key, or nil if it does not exist or has expired.
+func (s *MemoryFlashStorage) Get(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	if entry.expired(time.Now()) {
+		delete(s.entries, key)
+		return nil, nil
+	}
+
+	return entry.val, nil
+}
+
+// GetAndDelete atomically returns the value of the key, or nil if it does not exist or has expired,
+// and removes the key.
+func (s *MemoryFlashStorage) GetAndDelete(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	delete(s.entries, key)
+
+	if entry.expired(time.Now()) {
+		return nil, nil
+	}
+
+	return entry.val, nil
+}
+
+// Set stores the value of the key, 0 expiration means no expiration.
+func (s *MemoryFlashStorage) Set(key string, val []byte, exp time.Duration) error {
+	if key == "" || len(val) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	entry := memoryFlashEntry{val: append([]byte(nil), val...)}
+	if exp > 0 {
+		entry.expiresAt = now.Add(exp)
+	}
+	s.entries[key] = entry
+
+	return nil
+}
+
+// Delete removes the key.
+func (s *MemoryFlashStorage) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// Reset removes all keys.
+func (s *MemoryFlashStorage) Reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = make(map[string]memoryFlashEntry)
+	return nil
+}
+
+// Close does nothing, it is present to implement fiber.Storage.
+func (s *MemoryFlashStorage) Close() error {
+	return nil
+}
+
+func (s *MemoryFlashStorage) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < memoryFlashStorageSweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, entry := range s.entries {
+		if entry.expired(now) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package fibernetia
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// getDeleteFallbackStorage hides GetAndDelete of the wrapped storage,
+// so that flash data is consumed with Get and Delete.
+type getDeleteFallbackStorage struct {
+	fiber.Storage
+}
+
+// nextStorageFlashRequest returns a request, which carries the visitor id cookie of the previous request.
+func nextStorageFlashRequest(prev *fasthttp.RequestCtx) *fasthttp.RequestCtx {
+	ctx := &fasthttp.RequestCtx{}
+	if id := prev.Request.Header.Cookie(defaultStorageFlashCookieName); len(id) > 0 {
+		ctx.Request.Header.SetCookieBytesKV([]byte(defaultStorageFlashCookieName), id)
+	}
+	return ctx
+}
+
+func TestStorageFlashProvider_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		storage func() fiber.Storage
+	}{
+		{"get and delete", func() fiber.Storage { return NewMemoryFlashStorage() }},
+		{"get then delete", func() fiber.Storage { return getDeleteFallbackStorage{NewMemoryFlashStorage()} }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			storage := tt.storage()
+			p, err := NewStorageFlashProvider(storage)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			// POST: flash the data before redirecting.
+			post := &fasthttp.RequestCtx{}
+			if err = p.Flash(post, "success", "saved"); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err = p.Flash(post, "errors", ValidationErrors{"name": "required"}); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err = p.FlashClearHistory(post); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			// GET: read the data flashed by the POST.
+			get := nextStorageFlashRequest(post)
+
+			got, err := p.Get(get, "success")
+			if err != nil || got != "saved" {
+				t.Fatalf("got %v, %v, want %q", got, err, "saved")
+			}
+
+			got, err = p.Get(get, "errors")
+			if ve, ok := got.(ValidationErrors); err != nil || !ok || ve["name"] != "required" {
+				t.Fatalf("got %#v, %v, want validation errors", got, err)
+			}
+
+			clearHistory, err := p.ShouldClearHistory(get)
+			if err != nil || !clearHistory {
+				t.Fatalf("got %v, %v, want clear history", clearHistory, err)
+			}
+
+			// The data is removed from the storage, so it is read only once.
+			id := string(get.Request.Header.Cookie(defaultStorageFlashCookieName))
+			if bs, _ := storage.Get(defaultStorageFlashKeyPrefix + id); bs != nil {
+				t.Fatalf("got %s in the storage, want consumed flash data", bs)
+			}
+			if got, _ = p.Get(nextStorageFlashRequest(get), "success"); got != nil {
+				t.Fatalf("got %v on the next request, want nil", got)
+			}
+		})
+	}
+}
+
+func TestStorageFlashProvider_VisitorCookie(t *testing.T) {
+	t.Parallel()
+
+	p, err := NewMemoryFlashProvider(WithStorageFlashCookieSecure())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	first := &fasthttp.RequestCtx{}
+	if err = p.Flash(first, "success", "saved"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	cookie := fasthttp.AcquireCookie()
+	defer fasthttp.ReleaseCookie(cookie)
+
+	cookie.SetKey(defaultStorageFlashCookieName)
+	if !first.Response.Header.Cookie(cookie) {
+		t.Fatal("visitor id cookie is not set")
+	}
+	if len(cookie.Value()) == 0 || !cookie.HTTPOnly() || !cookie.Secure() {
+		t.Fatalf("got cookie %s, want secure http only visitor id", cookie)
+	}
+
+	// Flashing again in the same request keeps the id.
+	if err = p.Flash(first, "error", "failed"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The next request reuses the id of its cookie and does not set a new one.
+	second := nextStorageFlashRequest(first)
+	if err = p.Flash(second, "success", "saved again"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(second.Response.Header.PeekCookie(defaultStorageFlashCookieName)) > 0 {
+		t.Fatal("visitor id cookie is set again")
+	}
+
+	got, err := p.Get(nextStorageFlashRequest(second), "success")
+	if err != nil || got != "saved again" {
+		t.Fatalf("got %v, %v, want %q", got, err, "saved again")
+	}
+}
+
+func TestStorageFlashProvider_TTL(t *testing.T) {
+	t.Parallel()
+
+	p, err := NewMemoryFlashProvider(WithStorageFlashTTL(time.Millisecond))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	post := &fasthttp.RequestCtx{}
+	if err = p.Flash(post, "success", "saved"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	if got, err := p.Get(nextStorageFlashRequest(post), "success"); err != nil || got != nil {
+		t.Fatalf("got %v, %v, want expired flash data", got, err)
+	}
+}
+
+func TestStorageFlashProvider_Reflash(t *testing.T) {
+	t.Parallel()
+
+	p, err := NewMemoryFlashProvider()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	post := &fasthttp.RequestCtx{}
+	if err = p.Flash(post, "success", "saved"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The data is consumed by the request, but kept for the next one.
+	get := nextStorageFlashRequest(post)
+	if err = p.Reflash(get); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got, err := p.Get(nextStorageFlashRequest(get), "success")
+	if err != nil || got != "saved" {
+		t.Fatalf("got %v, %v, want %q", got, err, "saved")
+	}
+}
+
+func TestMemoryFlashStorage_Expiration(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		read func(s *MemoryFlashStorage, key string) ([]byte, error)
+	}{
+		{"get", (*MemoryFlashStorage).Get},
+		{"get and delete", (*MemoryFlashStorage).GetAndDelete},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := NewMemoryFlashStorage()
+			_ = s.Set("expiring", []byte("a"), time.Millisecond)
+			_ = s.Set("lasting", []byte("b"), 0)
+
+			time.Sleep(10 * time.Millisecond)
+
+			// Expired entries are removed lazily on access.
+			if bs, err := tt.read(s, "expiring"); err != nil || bs != nil {
+				t.Fatalf("got %s, %v, want expired entry", bs, err)
+			}
+			if _, ok := s.entries["expiring"]; ok {
+				t.Fatal("expired entry is not removed on access")
+			}
+
+			if bs, err := tt.read(s, "lasting"); err != nil || string(bs) != "b" {
+				t.Fatalf("got %s, %v, want entry without expiration", bs, err)
+			}
+		})
+	}
+}
+
+func TestMemoryFlashStorage_Sweep(t *testing.T) {
+	t.Parallel()
+
+	s := NewMemoryFlashStorage()
+	_ = s.Set("expiring", []byte("a"), time.Millisecond)
+
+	time.Sleep(10 * time.Millisecond)
+
+	// The sweep has run on the first write, so it does not run again within the interval.
+	_ = s.Set("other", []byte("b"), 0)
+	if _, ok := s.entries["expiring"]; !ok {
+		t.Fatal("expired entry is swept before the sweep interval")
+	}
+
+	s.lastSweep = time.Now().Add(-memoryFlashStorageSweepInterval)
+	_ = s.Set("other", []byte("b"), 0)
+	if _, ok := s.entries["expiring"]; ok {
+		t.Fatal("expired entry is not swept on write")
+	}
+}
@@ -0,0 +1,45 @@
+package fibernetia
+
+import "testing"
+
+func TestFlashValue_KeepsType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		val  any
+		want func(got any) bool
+	}{
+		{"validation errors", ValidationErrors{"name": "required"}, func(got any) bool {
+			v, ok := got.(ValidationErrors)
+			return ok && v["name"] == "required"
+		}},
+		{"flash messages", FlashMessages{"toast": "saved"}, func(got any) bool {
+			v, ok := got.(FlashMessages)
+			return ok && v["toast"] == "saved"
+		}},
+		{"plain map", map[string]any{"toast": "saved"}, func(got any) bool {
+			v, ok := got.(map[string]any)
+			return ok && v["toast"] == "saved"
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := marshalFlashValue(tt.val)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			got, err := unmarshalFlashValue(data)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !tt.want(got) {
+				t.Fatalf("got %#v, want %#v", got, tt.val)
+			}
+		})
+	}
+}
@@ -14,6 +14,9 @@ const (
 	headerInertiaPartialComponent = "X-Inertia-Partial-Component"
 	headerInertiaVersion          = "X-Inertia-Version"
 	headerInertiaReset            = "X-Inertia-Reset"
+	headerInertiaErrorBag         = "X-Inertia-Error-Bag"
+	headerInertiaExceptOnceProps  = "X-Inertia-Except-Once-Props"
+	headerInertiaMergeIntent      = "X-Inertia-Infinite-Scroll-Merge-Intent"
 	headerVary                    = "Vary"
 	headerContentType             = "Content-Type"
 )
@@ -47,6 +50,13 @@ func setResponseStatus(ctx *fasthttp.RequestCtx, status int) {
 	ctx.Response.SetStatusCode(status)
 }
 
+// isEmptyResponse returns true if the handler has not written anything: the status is 200 and the body is empty.
+func isEmptyResponse(ctx *fasthttp.RequestCtx) bool {
+	return ctx.Response.StatusCode() == fasthttp.StatusOK &&
+		!ctx.Response.IsBodyStream() &&
+		len(ctx.Response.Body()) == 0
+}
+
 func onlyFromRequest(ctx *fasthttp.RequestCtx) []string {
 	header := string(ctx.Request.Header.Peek(headerInertiaPartialData))
 	if header == "" {
@@ -71,10 +81,28 @@ func resetFromRequest(ctx *fasthttp.RequestCtx) []string {
 	return strings.Split(header, ",")
 }
 
+func exceptOncePropsFromRequest(ctx *fasthttp.RequestCtx) []string {
+	header := string(ctx.Request.Header.Peek(headerInertiaExceptOnceProps))
+	if header == "" {
+		return nil
+	}
+	return strings.Split(header, ",")
+}
+
+const mergeIntentPrepend = "prepend"
+
+func mergeIntentFromRequest(ctx *fasthttp.RequestCtx) string {
+	return string(ctx.Request.Header.Peek(headerInertiaMergeIntent))
+}
+
 func partialComponentFromRequest(ctx *fasthttp.RequestCtx) string {
 	return string(ctx.Request.Header.Peek(headerInertiaPartialComponent))
 }
 
+func errorBagFromRequest(ctx *fasthttp.RequestCtx) string {
+	return string(ctx.Request.Header.Peek(headerInertiaErrorBag))
+}
+
 func inertiaVersionFromRequest(ctx *fasthttp.RequestCtx) string {
 	return string(ctx.Request.Header.Peek(headerInertiaVersion))
 }
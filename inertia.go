@@ -9,14 +9,17 @@ import (
 	"log"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/valyala/fasthttp"
 )
 
 // Inertia is the main Gonertia structure, which contains all the logic for being an Inertia adapter.
 type Inertia struct {
-	rootTemplate     *template.Template
-	rootTemplateHTML string
+	rootTemplate               *rootTemplate
+	rootTemplates              map[string]*rootTemplate
+	rootTemplateRules          []rootTemplateRule
+	rootTemplateReloadInterval time.Duration
 
 	sharedPropsMu sync.RWMutex
 	sharedProps   Props
@@ -27,13 +30,32 @@ type Inertia struct {
 	sharedTemplateFuncsMu sync.RWMutex
 	sharedTemplateFuncs   TemplateFuncs
 
-	flash FlashProvider
+	flash     FlashProvider
+	flashKeys []string
+	flashProp string
 
-	ssrURL        string
+	versionMismatchHandler VersionMismatchHandler
+
+	ssrEndpoint   *ssrEndpoint
 	ssrHTTPClient *fasthttp.Client
+	ssrTimeout    time.Duration
+	ssrBreaker    *ssrCircuitBreaker
+	ssrRenderHook SSRRenderHook
+	ssrHealthHook SSRHealthHook
+
+	ssrExcludedComponents []string
+	ssrFilter             SSRFilter
+
+	ssrProcess *SSRProcess
+
+	ssrCache        *ssrCache
+	ssrCacheKeyFunc SSRCacheKeyFunc
+
+	htmlStreaming bool
 
 	containerID    string
 	version        string
+	versionFunc    VersionFunc
 	encryptHistory bool
 	jsonMarshaller JSONMarshaller
 	logger         Logger
@@ -46,7 +68,7 @@ func New(rootTemplateHTML string, opts ...Option) (*Inertia, error) {
 	}
 
 	i := newInertia(func(i *Inertia) {
-		i.rootTemplateHTML = rootTemplateHTML
+		i.rootTemplate = newRootTemplate(rootTemplateHTML)
 	})
 
 	for _, opt := range opts {
@@ -55,6 +77,10 @@ func New(rootTemplateHTML string, opts ...Option) (*Inertia, error) {
 		}
 	}
 
+	if err := i.afterOptions(); err != nil {
+		return nil, fmt.Errorf("initialize inertia: %w", err)
+	}
+
 	return i, nil
 }
 
@@ -65,7 +91,7 @@ func NewFromTemplate(rootTemplate *template.Template, opts ...Option) (*Inertia,
 	}
 
 	i := newInertia(func(i *Inertia) {
-		i.rootTemplate = rootTemplate
+		i.rootTemplate = newPrebuiltRootTemplate(rootTemplate)
 	})
 
 	for _, opt := range opts {
@@ -74,27 +100,39 @@ func NewFromTemplate(rootTemplate *template.Template, opts ...Option) (*Inertia,
 		}
 	}
 
+	if err := i.afterOptions(); err != nil {
+		return nil, fmt.Errorf("initialize inertia: %w", err)
+	}
+
 	return i, nil
 }
 
 // NewFromFileFS reads all bytes from the root template file and then initializes Inertia.
 func NewFromFileFS(rootFS fs.FS, rootTemplatePath string, opts ...Option) (*Inertia, error) {
-	bs, err := fs.ReadFile(rootFS, rootTemplatePath)
+	readFile := func() ([]byte, error) {
+		return fs.ReadFile(rootFS, rootTemplatePath)
+	}
+
+	bs, err := readFile()
 	if err != nil {
 		return nil, fmt.Errorf("read file %q: %w", rootTemplatePath, err)
 	}
 
-	return NewFromBytes(bs, opts...)
+	return NewFromBytes(bs, append([]Option{withRootTemplateFile(rootTemplatePath, readFile)}, opts...)...)
 }
 
 // NewFromFile reads all bytes from the root template file and then initializes Inertia.
 func NewFromFile(rootTemplatePath string, opts ...Option) (*Inertia, error) {
-	bs, err := os.ReadFile(rootTemplatePath)
+	readFile := func() ([]byte, error) {
+		return os.ReadFile(rootTemplatePath)
+	}
+
+	bs, err := readFile()
 	if err != nil {
 		return nil, fmt.Errorf("read file %q: %w", rootTemplatePath, err)
 	}
 
-	return NewFromBytes(bs, opts...)
+	return NewFromBytes(bs, append([]Option{withRootTemplateFile(rootTemplatePath, readFile)}, opts...)...)
 }
 
 // NewFromReader reads all bytes from the reader with root template html and then initializes Inertia.
@@ -123,12 +161,35 @@ func newInertia(f func(i *Inertia)) *Inertia {
 		sharedProps:         make(Props),
 		sharedTemplateData:  make(TemplateData),
 		sharedTemplateFuncs: make(TemplateFuncs),
+		rootTemplates:       make(map[string]*rootTemplate),
 		ssrHTTPClient:       &fasthttp.Client{},
+		ssrTimeout:          defaultSSRTimeout,
+		ssrBreaker:          newSSRCircuitBreaker(defaultSSRFailureThreshold, defaultSSRCooldown),
+		ssrCacheKeyFunc:     defaultSSRCacheKey,
+		flashKeys:           []string{"success", "error"},
+		flashProp:           "flash",
 	}
 	f(i)
 	return i
 }
 
+// afterOptions validates and completes the initialization, which depends on several options.
+func (i *Inertia) afterOptions() error {
+	if err := i.validateRootTemplates(); err != nil {
+		return err
+	}
+
+	if i.ssrEndpoint != nil {
+		i.ssrHTTPClient = i.ssrEndpoint.client(i.ssrHTTPClient, i.ssrTimeout)
+	}
+
+	if i.ssrProcess != nil {
+		i.ssrProcess.useLogger(i.logger)
+	}
+
+	return nil
+}
+
 // Logger defines an interface for debug messages.
 type Logger interface {
 	Printf(format string, v ...any)
@@ -143,6 +204,17 @@ type FlashProvider interface {
 	FlashClearHistory(ctx context.Context) error
 }
 
+// FlashReflasher is an optional interface for flash data providers,
+// which consume all flash data of the request at once.
+// Reflash keeps the flash data, that has not been read yet, for the next request.
+type FlashReflasher interface {
+	Reflash(ctx context.Context) error
+}
+
+// VersionMismatchHandler handles Inertia requests with an outdated assets version.
+// It is called instead of the handler, after pending flash data has been kept for the next request.
+type VersionMismatchHandler func(ctx *fasthttp.RequestCtx, clientVersion, serverVersion string)
+
 // ShareProp adds passed prop to shared props.
 func (i *Inertia) ShareProp(key string, val any) {
 	i.sharedPropsMu.Lock()
@@ -177,15 +249,17 @@ func (i *Inertia) ShareTemplateData(key string, val any) {
 }
 
 // ShareTemplateFunc adds the passed value to the shared template func map.
+// The root template is parsed again with the new func on next render.
 // If no root template HTML string has been defined, it returns an error.
 func (i *Inertia) ShareTemplateFunc(key string, val any) error {
-	if i.rootTemplateHTML == "" {
+	if i.rootTemplate.prebuilt {
 		return fmt.Errorf("undefined root template html string")
 	}
 
 	i.sharedTemplateFuncsMu.Lock()
-	defer i.sharedTemplateFuncsMu.Unlock()
-
 	i.sharedTemplateFuncs[key] = val
+	i.sharedTemplateFuncsMu.Unlock()
+
+	i.invalidateRootTemplates()
 	return nil
 }
@@ -1,8 +1,6 @@
 package fibernetia
 
 import (
-	"bytes"
-
 	"github.com/valyala/fasthttp"
 )
 
@@ -10,141 +8,119 @@ import (
 // All handlers that can be handled by Inertia should be wrapped with this.
 func (i *Inertia) Middleware(next fasthttp.RequestHandler) fasthttp.RequestHandler {
 	return func(ctx *fasthttp.RequestCtx) {
-		// Set header Vary to "X-Inertia".
-		setInertiaVaryInResponse(ctx)
-
-		// Resolve validation errors and clear history from the flash data provider.
-		{
-			ctx = i.resolveValidationErrors(ctx)
-			ctx = i.resolveClearHistory(ctx)
-		}
-
-		if !IsInertiaRequest(ctx) {
+		_ = i.handle(ctx, func(ctx *fasthttp.RequestCtx) error {
 			next(ctx)
-			return
-		}
-
-		// Wrap response so we can capture status & body.
-		w2 := buildInertiaResponseWrapper(&ctx.Response)
-
-		// Call the next handler with original ctx.
-		next(ctx)
-
-		// If Inertia version changed, force client-side reload.
-		if string(ctx.Method()) == fasthttp.MethodGet && inertiaVersionFromRequest(ctx) != i.version {
-			i.Location(ctx, string(ctx.URI().RequestURI()))
-			return
-		}
+			return nil
+		})
+	}
+}
 
-		// Copy buffered response back before finishing.
-		defer i.copyWrapperResponse(ctx, w2)
+// handle runs the Inertia request lifecycle around next. If next returns
+// an error, the response is left untouched and the error is returned to the caller.
+func (i *Inertia) handle(ctx *fasthttp.RequestCtx, next func(ctx *fasthttp.RequestCtx) error) error {
+	// Set header Vary to "X-Inertia".
+	setInertiaVaryInResponse(ctx)
 
-		// Handle empty response (redirect back).
-		if w2.StatusCode() == fasthttp.StatusOK && w2.IsEmpty() {
-			i.Back(ctx)
-		}
+	// If Inertia version changed, force client-side reload without calling the handler.
+	if i.isVersionMismatch(ctx) {
+		i.handleVersionMismatch(ctx)
+		return nil
+	}
 
-		// For PUT/PATCH/DELETE → force 303 instead of 302.
-		if w2.StatusCode() == fasthttp.StatusFound && isSeeOtherRedirectMethod(string(ctx.Method())) {
-			setResponseStatus(ctx, fasthttp.StatusSeeOther)
-		}
+	// Resolve validation errors and clear history from the flash data provider.
+	{
+		i.resolveValidationErrors(ctx)
+		i.resolveClearHistory(ctx)
 	}
-}
 
-func (i *Inertia) resolveValidationErrors(ctx *fasthttp.RequestCtx) *fasthttp.RequestCtx {
-	if i.flash == nil {
-		return ctx
+	if !IsInertiaRequest(ctx) {
+		return next(ctx)
 	}
 
-	val, err := i.flash.Get(ctx, "errors")
-	if err != nil {
-		i.logger.Printf("get validation errors from flash provider error: %s", err)
-		return ctx
+	// Handlers write directly to ctx.Response, so the real status and body can be inspected after the call.
+	if err := next(ctx); err != nil {
+		return err
 	}
 
-	validationErrors, ok := val.(ValidationErrors)
-	if !ok || len(validationErrors) == 0 {
-		return ctx
+	// Handle empty response (redirect back).
+	if isEmptyResponse(ctx) {
+		i.Back(ctx)
 	}
 
-	result := SetValidationErrors(ctx, validationErrors)
-	if newCtx, ok := result.(*fasthttp.RequestCtx); ok {
-		return newCtx
+	// For PUT/PATCH/DELETE → force 303 instead of 302.
+	if ctx.Response.StatusCode() == fasthttp.StatusFound && isSeeOtherRedirectMethod(string(ctx.Method())) {
+		setResponseStatus(ctx, fasthttp.StatusSeeOther)
 	}
 
-	return ctx
+	return nil
 }
 
-func (i *Inertia) resolveClearHistory(ctx *fasthttp.RequestCtx) *fasthttp.RequestCtx {
-	if i.flash == nil {
-		return ctx
+// isVersionMismatch returns true for Inertia GET requests, whose assets version differs from the current one.
+// Requests without the version header are never treated as mismatched.
+func (i *Inertia) isVersionMismatch(ctx *fasthttp.RequestCtx) bool {
+	if !IsInertiaRequest(ctx) || string(ctx.Method()) != fasthttp.MethodGet {
+		return false
 	}
 
-	clearHistory, err := i.flash.ShouldClearHistory(ctx)
-	if err != nil {
-		i.logger.Printf("get clear history flag from flash provider error: %s", err)
-		return ctx
-	}
+	clientVersion := inertiaVersionFromRequest(ctx)
+	return clientVersion != "" && clientVersion != i.resolveVersion(ctx)
+}
 
-	if clearHistory {
-		if newCtx, ok := ClearHistory(ctx).(*fasthttp.RequestCtx); ok {
-			ctx = newCtx
-		}
-	}
+// handleVersionMismatch keeps pending flash data for the next request
+// and forces the client to make a full page visit.
+func (i *Inertia) handleVersionMismatch(ctx *fasthttp.RequestCtx) {
+	i.reflash(ctx)
 
-	return ctx
-}
+	if i.versionMismatchHandler != nil {
+		i.versionMismatchHandler(ctx, inertiaVersionFromRequest(ctx), i.resolveVersion(ctx))
+		return
+	}
 
-func (i *Inertia) copyWrapperResponse(dst *fasthttp.RequestCtx, src *inertiaResponseWrapper) {
-	i.copyWrapperHeaders(dst, src)
-	i.copyWrapperStatusCode(dst, src)
-	i.copyWrapperBuffer(dst, src)
+	i.Location(ctx, string(ctx.URI().RequestURI()))
 }
 
-func (i *Inertia) copyWrapperBuffer(dst *fasthttp.RequestCtx, src *inertiaResponseWrapper) {
-	if _, err := dst.Write(src.buf.Bytes()); err != nil {
-		i.logger.Printf("cannot copy inertia response buffer: %s", err)
+func (i *Inertia) reflash(ctx *fasthttp.RequestCtx) {
+	reflasher, ok := i.flash.(FlashReflasher)
+	if !ok {
+		return
 	}
-}
 
-func (i *Inertia) copyWrapperStatusCode(dst *fasthttp.RequestCtx, src *inertiaResponseWrapper) {
-	dst.SetStatusCode(src.statusCode)
+	if err := reflasher.Reflash(ctx); err != nil {
+		i.logger.Printf("cannot reflash flash data: %s", err)
+	}
 }
 
-func (i *Inertia) copyWrapperHeaders(dst *fasthttp.RequestCtx, src *inertiaResponseWrapper) {
-	src.header.VisitAll(func(k, v []byte) {
-		dst.Response.Header.DelBytes(k)
-		dst.Response.Header.AddBytesKV(k, v)
-	})
-}
+func (i *Inertia) resolveValidationErrors(ctx *fasthttp.RequestCtx) {
+	if i.flash == nil {
+		return
+	}
 
-// inertiaResponseWrapper wraps fasthttp.RequestCtx to buffer output.
-type inertiaResponseWrapper struct {
-	statusCode int
-	buf        *bytes.Buffer
-	header     *fasthttp.ResponseHeader
-}
+	val, err := i.flash.Get(ctx, validationErrorsFlashKey(errorBagFromRequest(ctx)))
+	if err != nil {
+		i.logger.Printf("get validation errors from flash provider error: %s", err)
+		return
+	}
 
-func (w *inertiaResponseWrapper) StatusCode() int {
-	return w.statusCode
-}
+	validationErrors, ok := val.(ValidationErrors)
+	if !ok || len(validationErrors) == 0 {
+		return
+	}
 
-func (w *inertiaResponseWrapper) IsEmpty() bool {
-	return w.buf.Len() == 0
+	SetValidationErrors(ctx, validationErrors)
 }
 
-func (w *inertiaResponseWrapper) Write(p []byte) (int, error) {
-	return w.buf.Write(p)
-}
+func (i *Inertia) resolveClearHistory(ctx *fasthttp.RequestCtx) {
+	if i.flash == nil {
+		return
+	}
 
-func (w *inertiaResponseWrapper) WriteHeader(code int) {
-	w.statusCode = code
-}
+	clearHistory, err := i.flash.ShouldClearHistory(ctx)
+	if err != nil {
+		i.logger.Printf("get clear history flag from flash provider error: %s", err)
+		return
+	}
 
-func buildInertiaResponseWrapper(resp *fasthttp.Response) *inertiaResponseWrapper {
-	return &inertiaResponseWrapper{
-		statusCode: resp.StatusCode(),
-		buf:        bytes.NewBuffer(nil),
-		header:     &resp.Header,
+	if clearHistory {
+		ClearHistory(ctx)
 	}
 }
@@ -0,0 +1,209 @@
+package fibernetia
+
+import (
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/valyala/fasthttp"
+)
+
+func TestMiddleware_RedirectStatus(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		method  string
+		inertia bool
+		want    int
+	}{
+		{"inertia put", fasthttp.MethodPut, true, fasthttp.StatusSeeOther},
+		{"inertia patch", fasthttp.MethodPatch, true, fasthttp.StatusSeeOther},
+		{"inertia delete", fasthttp.MethodDelete, true, fasthttp.StatusSeeOther},
+		{"inertia post", fasthttp.MethodPost, true, fasthttp.StatusFound},
+		{"plain put", fasthttp.MethodPut, false, fasthttp.StatusFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			i, err := New("<html>{{ .inertia }}</html>")
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.Header.SetMethod(tt.method)
+			if tt.inertia {
+				ctx.Request.Header.Set(headerInertia, "true")
+			}
+
+			i.Middleware(func(ctx *fasthttp.RequestCtx) {
+				i.Redirect(ctx, "/users")
+			})(ctx)
+
+			if got := ctx.Response.StatusCode(); got != tt.want {
+				t.Fatalf("got status %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddleware_EmptyResponse(t *testing.T) {
+	t.Parallel()
+
+	i, err := New("<html>{{ .inertia }}</html>")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(fasthttp.MethodPut)
+	ctx.Request.Header.Set(headerInertia, "true")
+	ctx.Request.Header.SetReferer("/users/1/edit")
+
+	i.Middleware(func(*fasthttp.RequestCtx) {})(ctx)
+
+	// The empty response redirects back, with 303 for PUT.
+	if got := ctx.Response.StatusCode(); got != fasthttp.StatusSeeOther {
+		t.Fatalf("got status %d, want %d", got, fasthttp.StatusSeeOther)
+	}
+	if got := string(ctx.Response.Header.Peek("Location")); got != "/users/1/edit" {
+		t.Fatalf("got location %q, want %q", got, "/users/1/edit")
+	}
+}
+
+func TestMiddleware_VersionMismatch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		method   string
+		version  string
+		mismatch bool
+	}{
+		{"outdated get", fasthttp.MethodGet, "old", true},
+		{"current get", fasthttp.MethodGet, md5("v1"), false},
+		{"get without version", fasthttp.MethodGet, "", false},
+		{"outdated post", fasthttp.MethodPost, "old", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			flash, err := NewMemoryFlashProvider()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			i, err := New("<html>{{ .inertia }}</html>", WithVersion("v1"), WithFlashProvider(flash))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			// The previous request flashes data for this one.
+			prev := &fasthttp.RequestCtx{}
+			if err = flash.Flash(prev, "success", "saved"); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			ctx := nextStorageFlashRequest(prev)
+			ctx.Request.SetRequestURI("/users?page=2")
+			ctx.Request.Header.SetMethod(tt.method)
+			ctx.Request.Header.Set(headerInertia, "true")
+			if tt.version != "" {
+				ctx.Request.Header.Set(headerInertiaVersion, tt.version)
+			}
+
+			called := false
+			i.Middleware(func(ctx *fasthttp.RequestCtx) {
+				called = true
+				ctx.SetStatusCode(fasthttp.StatusNoContent)
+			})(ctx)
+
+			if called == tt.mismatch {
+				t.Fatalf("got handler called %v, want %v", called, !tt.mismatch)
+			}
+			if !tt.mismatch {
+				return
+			}
+
+			if got := ctx.Response.StatusCode(); got != fasthttp.StatusConflict {
+				t.Fatalf("got status %d, want %d", got, fasthttp.StatusConflict)
+			}
+			if got := string(ctx.Response.Header.Peek(headerInertiaLocation)); got != "/users?page=2" {
+				t.Fatalf("got location %q, want %q", got, "/users?page=2")
+			}
+
+			// The flash data is kept for the full page visit.
+			got, err := flash.Get(nextStorageFlashRequest(ctx), "success")
+			if err != nil || got != "saved" {
+				t.Fatalf("got %v, %v, want reflashed %q", got, err, "saved")
+			}
+		})
+	}
+}
+
+func TestMiddleware_ErrorBag(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		errorBag string
+		want     string
+	}{
+		{"without error bag", "", `{"email":"invalid"}`},
+		{"with error bag", "login", `{"login":{"email":"invalid"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			flash, err := NewMemoryFlashProvider()
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			i, err := New("<html>{{ .inertia }}</html>", WithFlashProvider(flash))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			post := &fasthttp.RequestCtx{}
+			post.Request.Header.SetMethod(fasthttp.MethodPost)
+			post.Request.Header.Set(headerInertia, "true")
+			post.Request.Header.Set(headerInertiaErrorBag, tt.errorBag)
+
+			i.Middleware(func(ctx *fasthttp.RequestCtx) {
+				SetValidationErrors(ctx, ValidationErrors{"email": "invalid"})
+				i.Back(ctx)
+			})(post)
+
+			get := nextStorageFlashRequest(post)
+			get.Request.SetRequestURI("/login")
+			get.Request.Header.Set(headerInertia, "true")
+			get.Request.Header.Set(headerInertiaErrorBag, tt.errorBag)
+
+			i.Middleware(func(ctx *fasthttp.RequestCtx) {
+				if err := i.Render(ctx, "Login"); err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+			})(get)
+
+			var p page
+			if err = json.Unmarshal(get.Response.Body(), &p); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			got, err := json.Marshal(p.Props["errors"])
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("got errors %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
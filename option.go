@@ -5,6 +5,9 @@ import (
 	"io"
 	"io/fs"
 	"log"
+	"os"
+	"path"
+	"time"
 
 	"github.com/valyala/fasthttp"
 )
@@ -16,6 +19,7 @@ type Option func(i *Inertia) error
 func WithVersion(version string) Option {
 	return func(i *Inertia) error {
 		i.version = md5(version)
+		i.versionFunc = nil
 		return nil
 	}
 }
@@ -27,6 +31,7 @@ func WithVersionFromFileFS(rootFS fs.FS, path string) Option {
 		if err != nil {
 			return fmt.Errorf("calculating md5 hash of manifest file: %w", err)
 		}
+		i.versionFunc = nil
 		return nil
 	}
 }
@@ -38,6 +43,40 @@ func WithVersionFromFile(path string) Option {
 		if err != nil {
 			return fmt.Errorf("calculating md5 hash of manifest file: %w", err)
 		}
+		i.versionFunc = nil
+		return nil
+	}
+}
+
+// WithVersionFromFileWatch returns Option that will set Inertia's version based on file checksum,
+// which is re-calculated when the file changes. The file is checked at most once per interval
+// (1 second by default). It is intended for development, when assets are rebuilt without a server restart.
+func WithVersionFromFileWatch(path string, interval ...time.Duration) Option {
+	return func(i *Inertia) error {
+		w, err := newFileVersionWatcher(path, firstOr(interval, time.Second), func() Logger { return i.logger })
+		if err != nil {
+			return fmt.Errorf("calculating md5 hash of manifest file: %w", err)
+		}
+		i.versionFunc = w.Version
+		return nil
+	}
+}
+
+// WithVersionFunc returns Option that will set the function, which returns Inertia's version per request.
+// The returned value is used as is.
+func WithVersionFunc(versionFunc VersionFunc) Option {
+	return func(i *Inertia) error {
+		i.versionFunc = versionFunc
+		return nil
+	}
+}
+
+// WithVersionMismatchHandler returns Option that will set the handler of Inertia requests with an outdated version.
+// By default, the client is forced to reload the page with a 409 response and the X-Inertia-Location header.
+// The handler replaces this behavior, call Inertia.Location from it to keep the default response.
+func WithVersionMismatchHandler(handler VersionMismatchHandler) Option {
+	return func(i *Inertia) error {
+		i.versionMismatchHandler = handler
 		return nil
 	}
 }
@@ -78,22 +117,37 @@ func WithContainerID(id string) Option {
 }
 
 // WithSSR returns Option that will enable server side rendering on Inertia.
+// The URL is either an http(s) URL of the SSR server, default is http://127.0.0.1:13714,
+// or a Unix socket URL, e.g. unix:///var/run/ssr.sock.
 func WithSSR(url ...string) Option {
 	return func(i *Inertia) error {
-		var u string
-		if len(url) > 0 {
-			u = url[0]
-		} else {
-			const defaultURL = "http://127.0.0.1:13714"
-			u = defaultURL
+		endpoint, err := parseSSRURL(firstOr(url, defaultSSRURL))
+		if err != nil {
+			return err
 		}
 
-		i.ssrURL = u
+		i.ssrEndpoint = endpoint
+		return nil
+	}
+}
+
+// WithSSRProcess returns Option that will enable server side rendering on Inertia with the managed SSR process.
+// Pages are rendered without SSR until the process is ready, and while it is restarting.
+// The process logs with Inertia's logger, unless WithSSRProcessLogger is used.
+func WithSSRProcess(process *SSRProcess) Option {
+	return func(i *Inertia) error {
+		if process == nil {
+			return fmt.Errorf("nil ssr process")
+		}
+		i.ssrEndpoint = process.endpoint
+		i.ssrProcess = process
 		return nil
 	}
 }
 
 // WithSSRHTTPClient returns Option that will set Inertia's SSR fasthttp client.
+// For Unix socket SSR URLs, a copy of the client that dials the socket with the SSR timeout is used,
+// unless the client has a custom Dial. The passed client is not modified.
 func WithSSRHTTPClient(ssrHTTPClient *fasthttp.Client) Option {
 	return func(i *Inertia) error {
 		i.ssrHTTPClient = ssrHTTPClient
@@ -101,6 +155,98 @@ func WithSSRHTTPClient(ssrHTTPClient *fasthttp.Client) Option {
 	}
 }
 
+// WithSSRExcludedComponents returns Option that will disable SSR for the components.
+// Patterns use path.Match syntax, e.g. "Admin/*" excludes all components in the Admin directory.
+func WithSSRExcludedComponents(patterns ...string) Option {
+	return func(i *Inertia) error {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid ssr excluded component pattern %q: %w", pattern, err)
+			}
+		}
+		i.ssrExcludedComponents = append(i.ssrExcludedComponents, patterns...)
+		return nil
+	}
+}
+
+// WithSSRFilter returns Option that will set the function, which decides whether the component is rendered with SSR.
+func WithSSRFilter(filter SSRFilter) Option {
+	return func(i *Inertia) error {
+		i.ssrFilter = filter
+		return nil
+	}
+}
+
+// WithSSRCache returns Option that will enable caching of SSR results.
+// Up to size results are kept for the ttl, the least recently used are evicted first.
+// By default, results are keyed by the hash of the marshalled page, see WithSSRCacheKeyFunc.
+func WithSSRCache(size int, ttl time.Duration) Option {
+	return func(i *Inertia) error {
+		if size <= 0 {
+			return fmt.Errorf("invalid ssr cache size: %d", size)
+		}
+		if ttl <= 0 {
+			return fmt.Errorf("invalid ssr cache ttl: %s", ttl)
+		}
+		i.ssrCache = newSSRCache(size, ttl)
+		return nil
+	}
+}
+
+// WithSSRCacheKeyFunc returns Option that will set the function, which returns SSR cache keys.
+func WithSSRCacheKeyFunc(keyFunc SSRCacheKeyFunc) Option {
+	return func(i *Inertia) error {
+		if keyFunc == nil {
+			return fmt.Errorf("nil ssr cache key func")
+		}
+		i.ssrCacheKeyFunc = keyFunc
+		return nil
+	}
+}
+
+// WithSSRTimeout returns Option that will set the timeout of requests to the SSR server (5 seconds by default).
+func WithSSRTimeout(timeout time.Duration) Option {
+	return func(i *Inertia) error {
+		if timeout <= 0 {
+			return fmt.Errorf("invalid ssr timeout: %s", timeout)
+		}
+		i.ssrTimeout = timeout
+		return nil
+	}
+}
+
+// WithSSRCircuitBreaker returns Option that will configure the SSR circuit breaker.
+// After threshold consecutive failures, SSR is skipped for the cooldown duration,
+// then a single request probes the SSR server again. By default, it opens after 3 failures for 10 seconds.
+func WithSSRCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(i *Inertia) error {
+		if threshold <= 0 {
+			return fmt.Errorf("invalid ssr circuit breaker threshold: %d", threshold)
+		}
+		if cooldown <= 0 {
+			return fmt.Errorf("invalid ssr circuit breaker cooldown: %s", cooldown)
+		}
+		i.ssrBreaker = newSSRCircuitBreaker(threshold, cooldown)
+		return nil
+	}
+}
+
+// WithSSRRenderHook returns Option that will set the hook called after every SSR render attempt.
+func WithSSRRenderHook(hook SSRRenderHook) Option {
+	return func(i *Inertia) error {
+		i.ssrRenderHook = hook
+		return nil
+	}
+}
+
+// WithSSRHealthHook returns Option that will set the hook called when the SSR server health changes.
+func WithSSRHealthHook(hook SSRHealthHook) Option {
+	return func(i *Inertia) error {
+		i.ssrHealthHook = hook
+		return nil
+	}
+}
+
 // WithFlashProvider returns Option that will set Inertia's flash data provider.
 func WithFlashProvider(flash FlashProvider) Option {
 	return func(i *Inertia) error {
@@ -109,6 +255,107 @@ func WithFlashProvider(flash FlashProvider) Option {
 	}
 }
 
+// WithFlashKeys returns Option that will set keys, which are read one by one from the flash data provider
+// and added to the flash prop. Messages flashed with Inertia.Flash are always added and need no keys.
+func WithFlashKeys(keys ...string) Option {
+	return func(i *Inertia) error {
+		i.flashKeys = keys
+		return nil
+	}
+}
+
+// WithFlashProp returns Option that will set the name of the flash prop.
+// If the name is empty, flash messages are added as top-level props.
+func WithFlashProp(name string) Option {
+	return func(i *Inertia) error {
+		i.flashProp = name
+		return nil
+	}
+}
+
+// WithRootTemplateReload returns Option that will re-read root template files, when they have changed,
+// at most once per interval (1 second by default). It is intended for development, so that
+// template edits show up without a server restart. Only templates read from files are reloaded,
+// i.e. created with NewFromFile, NewFromFileFS, WithRootTemplateFromFile or WithRootTemplateFromFileFS.
+// Inertia is not initialized, if no root template is read from a file.
+func WithRootTemplateReload(interval ...time.Duration) Option {
+	return func(i *Inertia) error {
+		reloadInterval := firstOr(interval, time.Second)
+		if reloadInterval <= 0 {
+			return fmt.Errorf("invalid root template reload interval: %s", reloadInterval)
+		}
+		i.rootTemplateReloadInterval = reloadInterval
+		return nil
+	}
+}
+
+// WithRootTemplate returns Option that will add the named root template, e.g. a separate layout
+// of the admin area. It is selected with SetRootTemplate or WithRootTemplateRule,
+// and shares template data and funcs with the default root template.
+func WithRootTemplate(name, rootTemplateHTML string) Option {
+	return func(i *Inertia) error {
+		if name == "" {
+			return fmt.Errorf("blank root template name")
+		}
+		if rootTemplateHTML == "" {
+			return fmt.Errorf("blank root template %q", name)
+		}
+		i.rootTemplates[name] = newRootTemplate(rootTemplateHTML)
+		return nil
+	}
+}
+
+// WithRootTemplateFromFile returns Option that will add the named root template read from the file.
+func WithRootTemplateFromFile(name, path string) Option {
+	return func(i *Inertia) (err error) {
+		if name == "" {
+			return fmt.Errorf("blank root template name")
+		}
+		i.rootTemplates[name], err = newRootTemplateFromFile(path, func() ([]byte, error) {
+			return os.ReadFile(path)
+		})
+		return err
+	}
+}
+
+// WithRootTemplateFromFileFS returns Option that will add the named root template read from the file in rootFS.
+func WithRootTemplateFromFileFS(name string, rootFS fs.FS, path string) Option {
+	return func(i *Inertia) (err error) {
+		if name == "" {
+			return fmt.Errorf("blank root template name")
+		}
+		i.rootTemplates[name], err = newRootTemplateFromFile(path, func() ([]byte, error) {
+			return fs.ReadFile(rootFS, path)
+		})
+		return err
+	}
+}
+
+// WithRootTemplateRule returns Option that will select the named root template for components
+// matching the pattern. Patterns use path.Match syntax, e.g. "Admin/*" selects the template
+// for all components in the Admin directory. Rules are checked in the order they were added.
+// The name must be added with WithRootTemplate or similar options, an empty name selects the default root template.
+func WithRootTemplateRule(pattern, name string) Option {
+	return func(i *Inertia) error {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid root template rule pattern %q: %w", pattern, err)
+		}
+		i.rootTemplateRules = append(i.rootTemplateRules, rootTemplateRule{pattern: pattern, name: name})
+		return nil
+	}
+}
+
+// WithHTMLStreaming returns Option that will enable streaming of HTML responses.
+// The part of the root template before {{ .inertiaHead }} or {{ .inertia }} (usually the <head>
+// with styles and preloads) is flushed to the client while the page is rendered with SSR.
+// Template execution errors are still returned by Render, but SSR is performed after the handler has returned.
+func WithHTMLStreaming(streaming ...bool) Option {
+	return func(i *Inertia) error {
+		i.htmlStreaming = firstOr[bool](streaming, true)
+		return nil
+	}
+}
+
 // WithEncryptHistory returns Option that will enable Inertia's global history encryption.
 func WithEncryptHistory(encryptHistory ...bool) Option {
 	return func(i *Inertia) error {
@@ -0,0 +1,259 @@
+package fibernetia
+
+import (
+	"cmp"
+	"math"
+	"strconv"
+
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	defaultPaginatePageName    = "page"
+	defaultPaginatePerPageName = "per_page"
+	defaultPaginatePerPage     = 15
+	defaultPaginateMaxPerPage  = 100
+	defaultPaginateLinksWindow = 3
+)
+
+// Paginated is a page of the paginated list. It is serialized as {"data": [...], "meta": {...}, "links": {...}},
+// and implements ScrollPaginator, so it can be used with Scroll as well as with MergePaginated for load-more UIs.
+type Paginated[T any] struct {
+	Data  []T             `json:"data"`
+	Meta  PaginationMeta  `json:"meta"`
+	Links PaginationLinks `json:"links"`
+
+	pageName string
+}
+
+// PaginationMeta describes the page of the paginated list.
+type PaginationMeta struct {
+	CurrentPage int `json:"current_page"`
+	LastPage    int `json:"last_page"`
+	PerPage     int `json:"per_page"`
+	Total       int `json:"total"`
+}
+
+// PaginationLinks are the URLs of the pages. Other query params of the request are preserved.
+type PaginationLinks struct {
+	First string           `json:"first"`
+	Last  string           `json:"last"`
+	Prev  *string          `json:"prev"`
+	Next  *string          `json:"next"`
+	Pages []PaginationLink `json:"pages"`
+}
+
+// PaginationLink is the URL of a numbered page.
+type PaginationLink struct {
+	Page   int    `json:"page"`
+	URL    string `json:"url"`
+	Active bool   `json:"active"`
+}
+
+// PageParams are the page and the number of items per page requested by the client.
+type PageParams struct {
+	Page    int
+	PerPage int
+}
+
+// Offset returns the number of items before the page, e.g. for SQL OFFSET.
+func (p PageParams) Offset() int {
+	return (p.Page - 1) * p.PerPage
+}
+
+// PaginateOption is an option parameter that modifies pagination.
+// Out of range values are clamped to the nearest valid value, as documented by each option.
+type PaginateOption func(o *paginateOptions)
+
+type paginateOptions struct {
+	pageName    string
+	perPageName string
+	perPage     int
+	maxPerPage  int
+	linksWindow int
+}
+
+func newPaginateOptions(opts []PaginateOption) *paginateOptions {
+	o := &paginateOptions{
+		pageName:    defaultPaginatePageName,
+		perPageName: defaultPaginatePerPageName,
+		perPage:     defaultPaginatePerPage,
+		maxPerPage:  defaultPaginateMaxPerPage,
+		linksWindow: defaultPaginateLinksWindow,
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// WithPaginatePageName returns PaginateOption that will set the query arg of the page. Default is "page",
+// which is also used if the name is blank.
+func WithPaginatePageName(name string) PaginateOption {
+	return func(o *paginateOptions) {
+		o.pageName = cmp.Or(name, defaultPaginatePageName)
+	}
+}
+
+// WithPaginatePerPageName returns PaginateOption that will set the query arg of the number of items per page.
+// Default is "per_page", which is also used if the name is blank.
+func WithPaginatePerPageName(name string) PaginateOption {
+	return func(o *paginateOptions) {
+		o.perPageName = cmp.Or(name, defaultPaginatePerPageName)
+	}
+}
+
+// WithPaginatePerPage returns PaginateOption that will set the number of items per page,
+// if the request does not have it. Default is 15, values below 1 are raised to 1.
+func WithPaginatePerPage(perPage int) PaginateOption {
+	return func(o *paginateOptions) {
+		o.perPage = max(perPage, 1)
+	}
+}
+
+// WithPaginateMaxPerPage returns PaginateOption that will limit the number of items per page
+// requested by the client. Default is 100, values below 1 are raised to 1.
+// If it is lower than the number of items per page, the latter is the limit.
+func WithPaginateMaxPerPage(maxPerPage int) PaginateOption {
+	return func(o *paginateOptions) {
+		o.maxPerPage = max(maxPerPage, 1)
+	}
+}
+
+// WithPaginateLinksWindow returns PaginateOption that will set the number of numbered page links
+// on each side of the current page. Default is 3, negative values are raised to 0.
+func WithPaginateLinksWindow(window int) PaginateOption {
+	return func(o *paginateOptions) {
+		o.linksWindow = max(window, 0)
+	}
+}
+
+// PageParamsFromRequest returns the page params from the "page" and "per_page" query args.
+// The page is at least 1 and small enough for the offset to fit into int,
+// and the number of items per page is limited by the max per page option.
+func PageParamsFromRequest(ctx *fasthttp.RequestCtx, opts ...PaginateOption) PageParams {
+	return newPaginateOptions(opts).pageParams(ctx)
+}
+
+func (o *paginateOptions) pageParams(ctx *fasthttp.RequestCtx) PageParams {
+	args := ctx.QueryArgs()
+
+	page, err := args.GetUint(o.pageName)
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	perPage, err := args.GetUint(o.perPageName)
+	if err != nil || perPage < 1 {
+		perPage = o.perPage
+	}
+
+	perPage = min(perPage, max(o.maxPerPage, o.perPage))
+
+	return PageParams{
+		// The page is limited, so that the offset does not overflow.
+		Page:    min(page, math.MaxInt/perPage),
+		PerPage: perPage,
+	}
+}
+
+// Paginate returns the page of the paginated list, e.g.:
+//
+//	params := fibernetia.PageParamsFromRequest(ctx)
+//	users, total := repo.Users(params.Offset(), params.PerPage)
+//	props["users"] = fibernetia.Paginate(ctx, users, total)
+//
+// The items are the items of the current page, and total is the number of items of all pages.
+// The options must be the same as passed to PageParamsFromRequest.
+func Paginate[T any](ctx *fasthttp.RequestCtx, items []T, total int, opts ...PaginateOption) Paginated[T] {
+	o := newPaginateOptions(opts)
+	params := o.pageParams(ctx)
+
+	if items == nil {
+		items = []T{}
+	}
+	total = max(total, 0)
+	lastPage := total / params.PerPage
+	if total%params.PerPage != 0 {
+		lastPage++
+	}
+	lastPage = max(lastPage, 1)
+
+	pageURL := paginationURLBuilder(ctx, o.pageName)
+
+	links := PaginationLinks{
+		First: pageURL(1),
+		Last:  pageURL(lastPage),
+		Pages: []PaginationLink{},
+	}
+	if params.Page > 1 {
+		prev := pageURL(min(params.Page-1, lastPage))
+		links.Prev = &prev
+	}
+	if params.Page < lastPage {
+		next := pageURL(params.Page + 1)
+		links.Next = &next
+	}
+	// The window is counted from the first page, so that page numbers near math.MaxInt do not overflow.
+	firstLink := max(params.Page-o.linksWindow, 1)
+	lastLink := min(lastPage, params.Page+min(o.linksWindow, max(lastPage-params.Page, 0)))
+	for n := range max(lastLink-firstLink+1, 0) {
+		page := firstLink + n
+		links.Pages = append(links.Pages, PaginationLink{
+			Page:   page,
+			URL:    pageURL(page),
+			Active: page == params.Page,
+		})
+	}
+
+	return Paginated[T]{
+		Data: items,
+		Meta: PaginationMeta{
+			CurrentPage: params.Page,
+			LastPage:    lastPage,
+			PerPage:     params.PerPage,
+			Total:       total,
+		},
+		Links:    links,
+		pageName: o.pageName,
+	}
+}
+
+// ScrollMetadata returns the scroll metadata of the page, which implements ScrollPaginator.
+func (p Paginated[T]) ScrollMetadata() ScrollMetadata {
+	meta := ScrollMetadata{
+		PageName:    p.pageName,
+		CurrentPage: p.Meta.CurrentPage,
+	}
+	if meta.PageName == "" {
+		meta.PageName = defaultPaginatePageName
+	}
+	if p.Meta.CurrentPage > 1 {
+		meta.PreviousPage = min(p.Meta.CurrentPage-1, p.Meta.LastPage)
+	}
+	if p.Meta.CurrentPage < p.Meta.LastPage {
+		meta.NextPage = p.Meta.CurrentPage + 1
+	}
+
+	return meta
+}
+
+var _ ScrollPaginator = Paginated[any]{}
+
+// paginationURLBuilder returns the func that builds the URL of the page from the request URL.
+func paginationURLBuilder(ctx *fasthttp.RequestCtx, pageName string) func(page int) string {
+	// The original path keeps escaped characters, e.g. %2F, which the decoded path loses.
+	path := string(ctx.URI().PathOriginal())
+
+	return func(page int) string {
+		args := fasthttp.AcquireArgs()
+		defer fasthttp.ReleaseArgs(args)
+
+		ctx.QueryArgs().CopyTo(args)
+		args.Set(pageName, strconv.Itoa(page))
+
+		return path + "?" + args.String()
+	}
+}
@@ -0,0 +1,110 @@
+package fibernetia
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestPaginate_ClampsOptions(t *testing.T) {
+	t.Parallel()
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI("/users?page=3&per_page=50")
+
+	users := Paginate(ctx, []int{5}, 10,
+		WithPaginatePageName(""),
+		WithPaginatePerPageName(""),
+		WithPaginatePerPage(0),
+		WithPaginateMaxPerPage(-1),
+		WithPaginateLinksWindow(-1),
+	)
+
+	// The client's per page is limited by the max per page, which is raised to 1.
+	if users.Meta.PerPage != 1 || users.Meta.CurrentPage != 3 || users.Meta.LastPage != 10 {
+		t.Fatalf("got meta %+v, want page 3 of 10 with 1 item per page", users.Meta)
+	}
+
+	// The links window is raised to 0, so only the current page is linked.
+	if len(users.Links.Pages) != 1 || users.Links.Pages[0].Page != 3 || !users.Links.Pages[0].Active {
+		t.Fatalf("got page links %+v, want the current page only", users.Links.Pages)
+	}
+
+	if users.ScrollMetadata().PageName != defaultPaginatePageName {
+		t.Fatalf("got page name %q, want %q", users.ScrollMetadata().PageName, defaultPaginatePageName)
+	}
+}
+
+func TestPageParamsFromRequest(t *testing.T) {
+	t.Parallel()
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI("/users?p=0&size=500")
+
+	params := PageParamsFromRequest(ctx,
+		WithPaginatePageName("p"),
+		WithPaginatePerPageName("size"),
+		WithPaginateMaxPerPage(20),
+	)
+
+	if params.Page != 1 || params.PerPage != 20 || params.Offset() != 0 {
+		t.Fatalf("got %+v, want page 1 with 20 items per page", params)
+	}
+
+	// A huge page is limited, so that the offset does not overflow.
+	ctx.Request.SetRequestURI("/users?page=" + strconv.Itoa(math.MaxInt) + "&per_page=20")
+
+	params = PageParamsFromRequest(ctx)
+	if offset := params.Offset(); offset < 0 || offset > math.MaxInt-params.PerPage {
+		t.Fatalf("got offset %d of %+v, want no overflow", offset, params)
+	}
+
+	users := Paginate(ctx, []int{}, math.MaxInt, WithPaginatePerPage(1), WithPaginateMaxPerPage(1))
+	if users.Meta.LastPage != math.MaxInt || len(users.Links.Pages) != 4 {
+		t.Fatalf("got meta %+v with %d page links, want the last of %d pages", users.Meta, len(users.Links.Pages), math.MaxInt)
+	}
+}
+
+func TestPaginate_Links(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		uri      string
+		wantPrev string
+		wantNext string
+	}{
+		{
+			name:     "query args are kept",
+			uri:      "/users?page=2&sort=name",
+			wantPrev: "/users?page=1&sort=name",
+			wantNext: "/users?page=3&sort=name",
+		},
+		{
+			name:     "escaped path",
+			uri:      "/teams/a%2Fb/my%20users%3F?page=2",
+			wantPrev: "/teams/a%2Fb/my%20users%3F?page=1",
+			wantNext: "/teams/a%2Fb/my%20users%3F?page=3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.SetRequestURI(tt.uri)
+
+			users := Paginate(ctx, []int{1}, 30, WithPaginatePerPage(10))
+
+			if users.Links.Prev == nil || *users.Links.Prev != tt.wantPrev {
+				t.Fatalf("got prev %v, want %q", users.Links.Prev, tt.wantPrev)
+			}
+			if users.Links.Next == nil || *users.Links.Next != tt.wantNext {
+				t.Fatalf("got next %v, want %q", users.Links.Next, tt.wantNext)
+			}
+		})
+	}
+}
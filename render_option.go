@@ -0,0 +1,117 @@
+package fibernetia
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/valyala/fasthttp"
+)
+
+// RenderOption is an option parameter that modifies a single RenderWithOptions call.
+// Render options win over the request context and Inertia's options.
+type RenderOption func(o *renderOptions) error
+
+type renderOptions struct {
+	status         int
+	headers        [][2]string
+	rootTemplate   *string
+	encryptHistory *bool
+	clearHistory   *bool
+	ssr            *bool
+	templateData   TemplateData
+}
+
+func newRenderOptions(opts []RenderOption) (*renderOptions, error) {
+	o := &renderOptions{}
+
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, fmt.Errorf("apply render option: %w", err)
+		}
+	}
+
+	return o, nil
+}
+
+// writeResponse sets the status code and headers of the render options to the response.
+func (o *renderOptions) writeResponse(ctx *fasthttp.RequestCtx) {
+	if o.status != 0 {
+		setResponseStatus(ctx, o.status)
+	}
+
+	for _, header := range o.headers {
+		ctx.Response.Header.Set(header[0], header[1])
+	}
+}
+
+// WithRenderStatus returns RenderOption that will set the response status code, e.g. 404 for a not found page.
+func WithRenderStatus(status int) RenderOption {
+	return func(o *renderOptions) error {
+		if status < 100 || status > 999 {
+			return fmt.Errorf("invalid render status code: %d", status)
+		}
+		o.status = status
+		return nil
+	}
+}
+
+// WithRenderHeader returns RenderOption that will set the response header.
+func WithRenderHeader(key, val string) RenderOption {
+	return func(o *renderOptions) error {
+		o.headers = append(o.headers, [2]string{key, val})
+		return nil
+	}
+}
+
+// WithRenderRootTemplate returns RenderOption that will select the named root template.
+// An empty name selects the default root template.
+func WithRenderRootTemplate(name string) RenderOption {
+	return func(o *renderOptions) error {
+		o.rootTemplate = &name
+		return nil
+	}
+}
+
+// WithRenderEncryptHistory returns RenderOption that will enable or disable history encryption of the page.
+func WithRenderEncryptHistory(encryptHistory ...bool) RenderOption {
+	return func(o *renderOptions) error {
+		val := firstOr[bool](encryptHistory, true)
+		o.encryptHistory = &val
+		return nil
+	}
+}
+
+// WithRenderClearHistory returns RenderOption that will make the client clear its history state.
+func WithRenderClearHistory(clearHistory ...bool) RenderOption {
+	return func(o *renderOptions) error {
+		val := firstOr[bool](clearHistory, true)
+		o.clearHistory = &val
+		return nil
+	}
+}
+
+// WithRenderSSR returns RenderOption that will enable or disable server side rendering of the page.
+// SSR cannot be enabled, if it is not configured with WithSSR or WithSSRProcess.
+func WithRenderSSR(ssr ...bool) RenderOption {
+	return func(o *renderOptions) error {
+		val := firstOr[bool](ssr, true)
+		o.ssr = &val
+		return nil
+	}
+}
+
+// WithRenderTemplateData returns RenderOption that will add the data to the root template data.
+func WithRenderTemplateData(templateData TemplateData) RenderOption {
+	return func(o *renderOptions) error {
+		if o.templateData == nil {
+			o.templateData = make(TemplateData, len(templateData))
+		}
+		maps.Copy(o.templateData, templateData)
+		return nil
+	}
+}
+
+// WithRenderTemplateDatum returns RenderOption that will add the single item to the root template data.
+func WithRenderTemplateDatum(key string, val any) RenderOption {
+	return WithRenderTemplateData(TemplateData{key: val})
+}
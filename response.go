@@ -1,13 +1,19 @@
 package fibernetia
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"html/template"
 	"maps"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/valyala/fasthttp"
 )
@@ -71,6 +77,26 @@ func (p DeferProp) Merge() DeferProp {
 	return p
 }
 
+// DeepMerge makes the client merge nested objects and arrays of the prop recursively.
+func (p DeferProp) DeepMerge() DeferProp {
+	p.merge, p.deepMerge = true, true
+	return p
+}
+
+// Prepend makes the client prepend the prop items instead of appending them.
+func (p DeferProp) Prepend() DeferProp {
+	p.merge, p.prepend = true, true
+	return p
+}
+
+// MatchOn makes the client merge array items with matching values at the paths instead of adding them,
+// e.g. MatchOn("id") for an array prop or MatchOn("data.id") for a paginated prop.
+func (p DeferProp) MatchOn(paths ...string) DeferProp {
+	p.merge = true
+	p.matchOn = append(slices.Clone(p.matchOn), paths...)
+	return p
+}
+
 func Defer(value any, group ...string) DeferProp {
 	return DeferProp{
 		Value: value,
@@ -94,6 +120,43 @@ func Always(value any) AlwaysProp {
 	return AlwaysProp{Value: value}
 }
 
+// OnceProp is a property that the client keeps across visits.
+// It is not resolved again while the client reports that it already has the prop.
+type OnceProp struct {
+	Value any
+	// Key identifies the prop on the client, so pages can share it. It is the prop name by default.
+	Key string
+	// ExpiresAt is the time the client's copy expires at. Zero time means it never expires.
+	ExpiresAt time.Time
+}
+
+func (p OnceProp) Prop() any {
+	return p.Value
+}
+
+// As sets the key, which identifies the prop on the client.
+func (p OnceProp) As(key string) OnceProp {
+	p.Key = key
+	return p
+}
+
+// Until sets the time the client's copy of the prop expires at.
+func (p OnceProp) Until(expiresAt time.Time) OnceProp {
+	p.ExpiresAt = expiresAt
+	return p
+}
+
+func Once(value any) OnceProp {
+	return OnceProp{Value: value}
+}
+
+func (p OnceProp) key(propName string) string {
+	if p.Key != "" {
+		return p.Key
+	}
+	return propName
+}
+
 // MergeProps is a property whose items will be merged instead of overwritten.
 type MergeProps struct {
 	mergesProps
@@ -109,6 +172,26 @@ func (p MergeProps) Merge() MergeProps {
 	return p
 }
 
+// DeepMerge makes the client merge nested objects and arrays of the prop recursively.
+func (p MergeProps) DeepMerge() MergeProps {
+	p.merge, p.deepMerge = true, true
+	return p
+}
+
+// Prepend makes the client prepend the prop items instead of appending them.
+func (p MergeProps) Prepend() MergeProps {
+	p.merge, p.prepend = true, true
+	return p
+}
+
+// MatchOn makes the client merge array items with matching values at the paths instead of adding them,
+// e.g. MatchOn("id") for an array prop or MatchOn("data.id") for a paginated prop.
+func (p MergeProps) MatchOn(paths ...string) MergeProps {
+	p.merge = true
+	p.matchOn = append(slices.Clone(p.matchOn), paths...)
+	return p
+}
+
 func Merge(value any) MergeProps {
 	return MergeProps{
 		Value:       value,
@@ -116,18 +199,70 @@ func Merge(value any) MergeProps {
 	}
 }
 
+// DeepMerge returns the prop, which the client merges recursively, e.g. a paginated payload with nested data.
+func DeepMerge(value any) MergeProps {
+	return Merge(value).DeepMerge()
+}
+
+// MergePaginated returns the paginated prop, whose items under the wrapper key ("data" by default)
+// are merged, while the rest of the payload (e.g. meta and links) is overwritten. It is intended
+// for load-more UIs, e.g. MergePaginated(Paginate(ctx, users, total)).MatchOn("data.id").
+func MergePaginated(value any, wrapper ...string) MergeProps {
+	p := Merge(value)
+	p.path = firstOr(wrapper, "data")
+	return p
+}
+
 var _ mergeable = MergeProps{}
 
 type mergeable interface {
-	shouldMerge() bool
+	mergeStrategy() mergesProps
 }
 
 type mergesProps struct {
-	merge bool
+	merge     bool
+	deepMerge bool
+	prepend   bool
+	matchOn   []string
+	// path is the path of the merged items inside the prop. The whole prop is merged if it is empty.
+	path string
+}
+
+func (p mergesProps) mergeStrategy() mergesProps {
+	return p
+}
+
+// ScrollMetadata describes the page of a paginated prop for the client's infinite scroll.
+// Page numbers are nil if there is no such page. They may be strings for cursor pagination.
+type ScrollMetadata struct {
+	PageName     string `json:"pageName"`
+	PreviousPage any    `json:"previousPage"`
+	NextPage     any    `json:"nextPage"`
+	CurrentPage  any    `json:"currentPage"`
+}
+
+// ScrollPaginator is a paginated prop value, which provides scroll metadata.
+type ScrollPaginator interface {
+	ScrollMetadata() ScrollMetadata
 }
 
-func (p mergesProps) shouldMerge() bool {
-	return p.merge
+// ScrollProp is a paginated property for the client's infinite scroll. Its items, which are kept
+// under the wrapper key ("data" by default), are appended or prepended to the loaded ones,
+// depending on the direction the client is scrolling in.
+type ScrollProp struct {
+	Value   ScrollPaginator
+	Wrapper string
+}
+
+func (p ScrollProp) Prop() any {
+	return p.Value
+}
+
+func Scroll(paginator ScrollPaginator, wrapper ...string) ScrollProp {
+	return ScrollProp{
+		Value:   paginator,
+		Wrapper: firstOr(wrapper, "data"),
+	}
 }
 
 // Proper is an interface for custom type, which provides property, that will be resolved.
@@ -152,8 +287,30 @@ type TryProperWithContext interface {
 }
 
 // ValidationErrors are messages, that will be stored in the "errors" prop.
+// If the request has an error bag, they are nested under the bag name.
 type ValidationErrors map[string]any
 
+// validationErrorsFlashKey returns the flash data provider key of the error bag validation errors.
+func validationErrorsFlashKey(errorBag string) string {
+	if errorBag == "" {
+		return "errors"
+	}
+	return "errors:" + errorBag
+}
+
+// FlashMessages are messages, that will be stored in the flash prop.
+type FlashMessages map[string]any
+
+// flashMessagesKey is the flash data provider key of messages flashed with Inertia.Flash.
+const flashMessagesKey = "flash"
+
+// Flash adds the message to the flash prop. Fiber handlers pass c.Context().
+// The message is shown by the next page if the response is a redirect,
+// or by the current page if it is rendered.
+func (i *Inertia) Flash(ctx *fasthttp.RequestCtx, key string, val any) {
+	SetFlashMessage(ctx, key, val)
+}
+
 // Location creates redirect response.
 func (i *Inertia) Location(ctx *fasthttp.RequestCtx, url string, status ...int) {
 	i.flashContext(ctx)
@@ -187,19 +344,20 @@ func (i *Inertia) Redirect(ctx *fasthttp.RequestCtx, url string, status ...int)
 func (i *Inertia) flashContext(ctx *fasthttp.RequestCtx) {
 	i.flashValidationErrorsFromContext(ctx)
 	i.flashClearHistoryFromContext(ctx)
+	i.flashMessagesFromContext(ctx)
 }
 
-func (i *Inertia) flashValidationErrorsFromContext(ct context.Context) {
+func (i *Inertia) flashValidationErrorsFromContext(ctx *fasthttp.RequestCtx) {
 	if i.flash == nil {
 		return
 	}
 
-	validationErrors := ValidationErrorsFromContext(ct)
+	validationErrors := ValidationErrorsFromContext(ctx)
 	if len(validationErrors) == 0 {
 		return
 	}
 
-	err := i.flash.Flash(ct, "errors", validationErrors)
+	err := i.flash.Flash(ctx, validationErrorsFlashKey(errorBagFromRequest(ctx)), validationErrors)
 	if err != nil {
 		i.logger.Printf("cannot flash validation errors: %s", err)
 	}
@@ -221,21 +379,48 @@ func (i *Inertia) flashClearHistoryFromContext(ct context.Context) {
 	}
 }
 
+func (i *Inertia) flashMessagesFromContext(ct context.Context) {
+	if i.flash == nil {
+		return
+	}
+
+	flashMessages := FlashFromContext(ct)
+	if len(flashMessages) == 0 {
+		return
+	}
+
+	err := i.flash.Flash(ct, flashMessagesKey, flashMessages)
+	if err != nil {
+		i.logger.Printf("cannot flash messages: %s", err)
+	}
+}
+
 // Render returns response with Inertia data.
-func (i *Inertia) Render(ctx *fasthttp.RequestCtx, component string, props ...Props) (err error) {
-	p, err := i.buildPage(ctx, component, firstOr(props, nil))
+func (i *Inertia) Render(ctx *fasthttp.RequestCtx, component string, props ...Props) error {
+	return i.RenderWithOptions(ctx, component, firstOr(props, nil))
+}
+
+// RenderWithOptions returns response with Inertia data, customized by the render options,
+// e.g. the status code, headers or root template of this response.
+func (i *Inertia) RenderWithOptions(ctx *fasthttp.RequestCtx, component string, props Props, opts ...RenderOption) error {
+	o, err := newRenderOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	p, err := i.buildPage(ctx, component, props, o)
 	if err != nil {
 		return fmt.Errorf("build page: %w", err)
 	}
 
 	if IsInertiaRequest(ctx) {
-		if err = i.doInertiaResponse(ctx, p); err != nil {
+		if err = i.doInertiaResponse(ctx, p, o); err != nil {
 			return fmt.Errorf("inertia response: %w", err)
 		}
 		return nil
 	}
 
-	if err = i.doHTMLResponse(ctx, p); err != nil {
+	if err = i.doHTMLResponse(ctx, p, o); err != nil {
 		return fmt.Errorf("html response: %w", err)
 	}
 
@@ -243,21 +428,44 @@ func (i *Inertia) Render(ctx *fasthttp.RequestCtx, component string, props ...Pr
 }
 
 type page struct {
-	Component      string              `json:"component"`
-	Props          Props               `json:"props"`
-	URL            string              `json:"url"`
-	Version        string              `json:"version"`
-	EncryptHistory bool                `json:"encryptHistory"`
-	ClearHistory   bool                `json:"clearHistory"`
-	DeferredProps  map[string][]string `json:"deferredProps,omitempty"`
-	MergeProps     []string            `json:"mergeProps,omitempty"`
+	Component      string                `json:"component"`
+	Props          Props                 `json:"props"`
+	URL            string                `json:"url"`
+	Version        string                `json:"version"`
+	EncryptHistory bool                  `json:"encryptHistory"`
+	ClearHistory   bool                  `json:"clearHistory"`
+	DeferredProps  map[string][]string   `json:"deferredProps,omitempty"`
+	MergeProps     []string              `json:"mergeProps,omitempty"`
+	PrependProps   []string              `json:"prependProps,omitempty"`
+	DeepMergeProps []string              `json:"deepMergeProps,omitempty"`
+	MatchPropsOn   []string              `json:"matchPropsOn,omitempty"`
+	ScrollProps    map[string]scrollProp `json:"scrollProps,omitempty"`
+	OnceProps      map[string]onceProp   `json:"onceProps,omitempty"`
+}
+
+// scrollProp is the page metadata of ScrollProp.
+type scrollProp struct {
+	ScrollMetadata
+	Reset bool `json:"reset"`
+}
+
+// onceProp is the page metadata of OnceProp, keyed by its key.
+type onceProp struct {
+	Prop string `json:"prop"`
+	// ExpiresAt is the expiration time in unix milliseconds.
+	ExpiresAt *int64 `json:"expiresAt"`
 }
 
-func (i *Inertia) buildPage(ctx *fasthttp.RequestCtx, component string, props Props) (*page, error) {
+func (i *Inertia) buildPage(ctx *fasthttp.RequestCtx, component string, props Props, o *renderOptions) (*page, error) {
 	props = i.collectProps(ctx, props)
 
 	deferredProps := i.resolveDeferredProps(ctx, component, props)
-	mergeProps := resolveMergeProps(ctx, props)
+	merges := resolveMergeProps(ctx, props)
+	scrollProps := resolveScrollProps(ctx, props)
+
+	// Once props are advertised only if they are on the page, i.e. requested by a partial reload.
+	props = filterRequestedProps(ctx, component, props)
+	onceProps := resolveOnceProps(props)
 
 	props, err := i.resolveProps(ctx, component, props)
 	if err != nil {
@@ -268,11 +476,16 @@ func (i *Inertia) buildPage(ctx *fasthttp.RequestCtx, component string, props Pr
 		Component:      component,
 		Props:          props,
 		URL:            string(ctx.RequestURI()),
-		Version:        i.version,
-		EncryptHistory: i.resolveEncryptHistory(ctx),
-		ClearHistory:   ClearHistoryFromContext(ctx),
+		Version:        i.resolveVersion(ctx),
+		EncryptHistory: i.resolveEncryptHistory(ctx, o),
+		ClearHistory:   resolveClearHistory(ctx, o),
 		DeferredProps:  deferredProps,
-		MergeProps:     mergeProps,
+		MergeProps:     merges.mergeProps,
+		PrependProps:   merges.prependProps,
+		DeepMergeProps: merges.deepMergeProps,
+		MatchPropsOn:   merges.matchPropsOn,
+		ScrollProps:    scrollProps,
+		OnceProps:      onceProps,
 	}, nil
 }
 
@@ -282,13 +495,22 @@ func (i *Inertia) resolveDeferredProps(ctx *fasthttp.RequestCtx, component strin
 	}
 
 	keysByGroups := make(map[string][]string)
+	collectDeferredProps(keysByGroups, props, "")
+
+	return keysByGroups
+}
+
+// collectDeferredProps adds the deferred props at any depth to their groups.
+// Nested props are added as dot-notation paths, which the client reloads them with.
+func collectDeferredProps(keysByGroups map[string][]string, props Props, prefix string) {
 	for key, val := range props {
-		if dp, ok := val.(DeferProp); ok {
-			keysByGroups[dp.Group] = append(keysByGroups[dp.Group], key)
+		switch typed := val.(type) {
+		case DeferProp:
+			keysByGroups[typed.Group] = append(keysByGroups[typed.Group], prefix+key)
+		case Props:
+			collectDeferredProps(keysByGroups, typed, prefix+key+".")
 		}
 	}
-
-	return keysByGroups
 }
 
 func (i *Inertia) collectProps(ctx *fasthttp.RequestCtx, props Props) Props {
@@ -296,16 +518,18 @@ func (i *Inertia) collectProps(ctx *fasthttp.RequestCtx, props Props) Props {
 
 	// Add validation errors.
 	{
-		result["errors"] = AlwaysProp{ValidationErrorsFromContext(ctx)}
+		result["errors"] = AlwaysProp{resolveValidationErrorsProp(ctx)}
 	}
 
-	// Add flash messages (success and error)
-	if i.flash != nil {
-		success, _ := i.flash.Get(ctx, "success")
-		errorMsg, _ := i.flash.Get(ctx, "error")
-		result["flash"] = map[string]any{
-			"success": success,
-			"error":   errorMsg,
+	// Add flash messages. They are consumed from the flash provider,
+	// so they are always sent, even if a partial reload does not request them.
+	if flashMessages := i.resolveFlashMessages(ctx); len(flashMessages) > 0 {
+		if i.flashProp == "" {
+			for key, val := range flashMessages {
+				result[key] = AlwaysProp{val}
+			}
+		} else {
+			result[i.flashProp] = AlwaysProp{flashMessages}
 		}
 	}
 
@@ -323,48 +547,189 @@ func (i *Inertia) collectProps(ctx *fasthttp.RequestCtx, props Props) Props {
 	return result
 }
 
-func resolveMergeProps(ctx *fasthttp.RequestCtx, props Props) []string {
+func resolveValidationErrorsProp(ctx *fasthttp.RequestCtx) ValidationErrors {
+	validationErrors := ValidationErrorsFromContext(ctx)
+
+	errorBag := errorBagFromRequest(ctx)
+	if errorBag == "" || len(validationErrors) == 0 {
+		return validationErrors
+	}
+
+	return ValidationErrors{errorBag: validationErrors}
+}
+
+// resolveFlashMessages returns messages flashed by the previous request
+// and messages flashed by the current one.
+func (i *Inertia) resolveFlashMessages(ctx *fasthttp.RequestCtx) FlashMessages {
+	result := make(FlashMessages)
+
+	if i.flash != nil {
+		for _, key := range i.flashKeys {
+			val, err := i.flash.Get(ctx, key)
+			if err != nil {
+				i.logger.Printf("get flash %q from flash provider error: %s", key, err)
+				continue
+			}
+			if val != nil {
+				result[key] = val
+			}
+		}
+
+		val, err := i.flash.Get(ctx, flashMessagesKey)
+		if err != nil {
+			i.logger.Printf("get flash messages from flash provider error: %s", err)
+		}
+		// Providers may return the flashed value as is or decoded as a plain map.
+		switch flashMessages := val.(type) {
+		case FlashMessages:
+			maps.Copy(result, flashMessages)
+		case map[string]any:
+			maps.Copy(result, flashMessages)
+		}
+	}
+
+	maps.Copy(result, FlashFromContext(ctx))
+
+	return result
+}
+
+// mergePropsMeta is the page metadata of mergeable props.
+type mergePropsMeta struct {
+	mergeProps     []string
+	prependProps   []string
+	deepMergeProps []string
+	matchPropsOn   []string
+}
+
+// resolveMergeProps returns the metadata of mergeable props, except the props reset by the client.
+// Keys are sorted, so the page JSON is stable.
+func resolveMergeProps(ctx *fasthttp.RequestCtx, props Props) mergePropsMeta {
 	resetProps := setOf(resetFromRequest(ctx))
 
-	var mergeProps []string
+	var meta mergePropsMeta
 	for key, val := range props {
 		if _, ok := resetProps[key]; ok {
 			continue
 		}
 
-		if m, ok := val.(mergeable); ok && m.shouldMerge() {
-			mergeProps = append(mergeProps, key)
+		// Scroll props are merged in the direction the client is scrolling in.
+		if sp, ok := val.(ScrollProp); ok {
+			path := key + "." + sp.Wrapper
+			if mergeIntentFromRequest(ctx) == mergeIntentPrepend {
+				meta.prependProps = append(meta.prependProps, path)
+			} else {
+				meta.mergeProps = append(meta.mergeProps, path)
+			}
+			continue
+		}
+
+		m, ok := val.(mergeable)
+		if !ok {
+			continue
+		}
+
+		strategy := m.mergeStrategy()
+		if !strategy.merge {
+			continue
+		}
+
+		mergePath := key
+		if strategy.path != "" {
+			mergePath += "." + strategy.path
+		}
+
+		switch {
+		case strategy.deepMerge:
+			meta.deepMergeProps = append(meta.deepMergeProps, mergePath)
+		case strategy.prepend:
+			meta.prependProps = append(meta.prependProps, mergePath)
+		default:
+			meta.mergeProps = append(meta.mergeProps, mergePath)
+		}
+
+		for _, path := range strategy.matchOn {
+			meta.matchPropsOn = append(meta.matchPropsOn, key+"."+path)
 		}
 	}
 
-	return mergeProps
+	slices.Sort(meta.mergeProps)
+	slices.Sort(meta.prependProps)
+	slices.Sort(meta.deepMergeProps)
+	slices.Sort(meta.matchPropsOn)
+
+	return meta
 }
 
-//nolint:gocognit
-func (i *Inertia) resolveProps(ctx *fasthttp.RequestCtx, component string, props Props) (Props, error) {
+func resolveScrollProps(ctx *fasthttp.RequestCtx, props Props) map[string]scrollProp {
+	resetProps := setOf(resetFromRequest(ctx))
+
+	scrollProps := make(map[string]scrollProp)
+	for key, val := range props {
+		sp, ok := val.(ScrollProp)
+		if !ok || sp.Value == nil {
+			continue
+		}
+
+		_, reset := resetProps[key]
+		scrollProps[key] = scrollProp{
+			ScrollMetadata: sp.Value.ScrollMetadata(),
+			Reset:          reset,
+		}
+	}
+
+	return scrollProps
+}
+
+func resolveOnceProps(props Props) map[string]onceProp {
+	onceProps := make(map[string]onceProp)
+	for key, val := range props {
+		op, ok := val.(OnceProp)
+		if !ok {
+			continue
+		}
+
+		meta := onceProp{Prop: key}
+		if !op.ExpiresAt.IsZero() {
+			expiresAt := op.ExpiresAt.UnixMilli()
+			meta.ExpiresAt = &expiresAt
+		}
+		onceProps[op.key(key)] = meta
+	}
+
+	return onceProps
+}
+
+// filterRequestedProps returns the props requested by the partial reload,
+// or the props without the ones skipped on first load.
+func filterRequestedProps(ctx *fasthttp.RequestCtx, component string, props Props) Props {
 	if isPartial(ctx, component) {
 		only, except := getOnlyAndExcept(ctx)
+		return filterPartialProps(props, "", only, except, len(only) == 0)
+	}
 
-		if len(only) > 0 {
-			for key, val := range props {
-				if _, ok := only[key]; ok {
-					continue
-				}
-				if _, ok := val.(AlwaysProp); ok {
-					continue
-				}
-				delete(props, key)
-			}
+	return withoutIgnoredFirstLoadProps(props)
+}
+
+// resolveProps resolves the props filtered by filterRequestedProps.
+//
+//nolint:gocognit
+func (i *Inertia) resolveProps(ctx *fasthttp.RequestCtx, component string, props Props) (Props, error) {
+	// Skip once props, which the client already has, unless they are reloaded explicitly.
+	if loaded := setOf(exceptOncePropsFromRequest(ctx)); len(loaded) > 0 {
+		only := make(map[string]struct{})
+		if isPartial(ctx, component) {
+			only, _ = getOnlyAndExcept(ctx)
 		}
-		for key := range except {
-			if _, ok := props[key].(AlwaysProp); ok {
+
+		for key, val := range props {
+			op, ok := val.(OnceProp)
+			if !ok {
 				continue
 			}
-			delete(props, key)
-		}
-	} else {
-		for key, val := range props {
-			if ifl, ok := val.(ignoreFirstLoad); ok && ifl.shouldIgnoreFirstLoad() {
+			if _, ok = only[key]; ok {
+				continue
+			}
+			if _, ok = loaded[op.key(key)]; ok {
 				delete(props, key)
 			}
 		}
@@ -431,6 +796,74 @@ func getOnlyAndExcept(ctx *fasthttp.RequestCtx) (only, except map[string]struct{
 	return setOf(onlyFromRequest(ctx)), setOf(exceptFromRequest(ctx))
 }
 
+// filterPartialProps returns the props requested by the partial reload. Keys of only and except
+// may be dot-notation paths of nested props, e.g. "auth.user.permissions". Nested props are copied,
+// so shared props are not modified. Always props are kept at any depth.
+func filterPartialProps(props Props, prefix string, only, except map[string]struct{}, included bool) Props {
+	result := make(Props, len(props))
+	for key, val := range props {
+		path := prefix + key
+
+		if _, ok := val.(AlwaysProp); ok {
+			result[key] = val
+			continue
+		}
+		if _, ok := except[path]; ok {
+			continue
+		}
+
+		_, requested := only[path]
+		nested, isNested := val.(Props)
+
+		if included || requested {
+			if isNested && hasNestedPath(except, path) {
+				val = filterPartialProps(nested, path+".", only, except, true)
+			}
+			result[key] = val
+			continue
+		}
+
+		// Look for requested and Always props deeper.
+		if isNested {
+			filtered := filterPartialProps(nested, path+".", only, except, false)
+			if len(filtered) > 0 || hasNestedPath(only, path) {
+				result[key] = filtered
+			}
+		}
+	}
+
+	return result
+}
+
+// hasNestedPath returns true if the set has a path nested in the path.
+func hasNestedPath(paths map[string]struct{}, path string) bool {
+	for p := range paths {
+		if strings.HasPrefix(p, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutIgnoredFirstLoadProps returns the props without optional and deferred props at any depth.
+// Nested props are copied, so shared props are not modified.
+func withoutIgnoredFirstLoadProps(props Props) Props {
+	result := make(Props, len(props))
+	for key, val := range props {
+		switch typed := val.(type) {
+		case ignoreFirstLoad:
+			if typed.shouldIgnoreFirstLoad() {
+				continue
+			}
+		case Props:
+			val = withoutIgnoredFirstLoadProps(typed)
+		}
+		result[key] = val
+	}
+
+	return result
+}
+
 func resolvePropVal(ct context.Context, val any) (_ any, err error) {
 	switch proper := val.(type) {
 	case Proper:
@@ -466,10 +899,32 @@ func resolvePropVal(ct context.Context, val any) (_ any, err error) {
 		}
 	}
 
+	if nested, ok := val.(Props); ok {
+		return resolveNestedProps(ct, nested)
+	}
+
 	return val, nil
 }
 
-func (i *Inertia) resolveEncryptHistory(ct context.Context) bool {
+// resolveNestedProps resolves the values of nested props into a copy of them.
+func resolveNestedProps(ct context.Context, props Props) (Props, error) {
+	result := make(Props, len(props))
+	for key, val := range props {
+		resolvedVal, err := resolvePropVal(ct, val)
+		if err != nil {
+			return nil, fmt.Errorf("resolve prop %q: %w", key, err)
+		}
+		result[key] = resolvedVal
+	}
+
+	return result, nil
+}
+
+func (i *Inertia) resolveEncryptHistory(ct context.Context, o *renderOptions) bool {
+	if o.encryptHistory != nil {
+		return *o.encryptHistory
+	}
+
 	encryptHistory, ok := EncryptHistoryFromContext(ct)
 	if ok {
 		return encryptHistory
@@ -478,7 +933,15 @@ func (i *Inertia) resolveEncryptHistory(ct context.Context) bool {
 	return i.encryptHistory
 }
 
-func (i *Inertia) doInertiaResponse(ctx *fasthttp.RequestCtx, page *page) error {
+func resolveClearHistory(ct context.Context, o *renderOptions) bool {
+	if o.clearHistory != nil {
+		return *o.clearHistory
+	}
+
+	return ClearHistoryFromContext(ct)
+}
+
+func (i *Inertia) doInertiaResponse(ctx *fasthttp.RequestCtx, page *page, o *renderOptions) error {
 	pageJSON, err := i.jsonMarshaller.Marshal(page)
 	if err != nil {
 		return fmt.Errorf("json marshal: %w", err)
@@ -487,6 +950,7 @@ func (i *Inertia) doInertiaResponse(ctx *fasthttp.RequestCtx, page *page) error
 	setInertiaInResponse(ctx)
 	setJSONResponse(ctx)
 	setResponseStatus(ctx, fasthttp.StatusOK)
+	o.writeResponse(ctx)
 
 	if _, err = ctx.Write(pageJSON); err != nil {
 		return fmt.Errorf("write bytes to response: %w", err)
@@ -495,41 +959,106 @@ func (i *Inertia) doInertiaResponse(ctx *fasthttp.RequestCtx, page *page) error
 	return nil
 }
 
-func (i *Inertia) doHTMLResponse(ctx *fasthttp.RequestCtx, page *page) (err error) {
-	if i.rootTemplate == nil {
-		i.rootTemplate, err = i.buildRootTemplate()
-		if err != nil {
-			return fmt.Errorf("build root template: %w", err)
-		}
+func (i *Inertia) doHTMLResponse(ctx *fasthttp.RequestCtx, page *page, o *renderOptions) error {
+	rootTemplate, err := i.getRootTemplate(ctx, page.Component, o)
+	if err != nil {
+		return fmt.Errorf("build root template: %w", err)
+	}
+
+	renderInertiaHTML, err := i.prepareInertiaHTML(ctx, page, o)
+	if err != nil {
+		return fmt.Errorf("prepare inertia html: %w", err)
+	}
+
+	if i.htmlStreaming {
+		return i.doStreamedHTMLResponse(ctx, rootTemplate, renderInertiaHTML, o)
 	}
 
-	templateData, err := i.buildTemplateData(ctx, page)
+	inertia, inertiaHead, err := renderInertiaHTML()
 	if err != nil {
-		return fmt.Errorf("build template data: %w", err)
+		return fmt.Errorf("build inertia html: %w", err)
 	}
 
+	templateData := i.buildTemplateData(ctx, inertia, inertiaHead, o)
+
 	setHTMLResponse(ctx)
+	o.writeResponse(ctx)
 
-	if err = i.rootTemplate.Execute(ctx, templateData); err != nil {
+	if err = rootTemplate.Execute(ctx, templateData); err != nil {
 		return fmt.Errorf("execute root template: %w", err)
 	}
 
 	return nil
 }
 
-func (i *Inertia) buildRootTemplate() (*template.Template, error) {
-	i.sharedTemplateFuncsMu.RLock()
-	defer i.sharedTemplateFuncsMu.RUnlock()
+// doStreamedHTMLResponse executes the root template with placeholders for the Inertia container and head,
+// so that template errors are still returned by Render. Then the response body is streamed:
+// the part of the template before the first placeholder (usually the <head> with styles and preloads)
+// is flushed right away, and the rest is written after SSR has finished.
+func (i *Inertia) doStreamedHTMLResponse(ctx *fasthttp.RequestCtx, rootTemplate *template.Template, renderInertiaHTML inertiaHTMLFunc, o *renderOptions) error {
+	nonce, err := streamPlaceholderNonce()
+	if err != nil {
+		return err
+	}
+	inertiaPlaceholder := "<!--inertia:" + nonce + "-->"
+	inertiaHeadPlaceholder := "<!--inertia-head:" + nonce + "-->"
+
+	templateData := i.buildTemplateData(ctx, template.HTML(inertiaPlaceholder), template.HTML(inertiaHeadPlaceholder), o)
+
+	var buf bytes.Buffer
+	if err = rootTemplate.Execute(&buf, templateData); err != nil {
+		return fmt.Errorf("execute root template: %w", err)
+	}
+	html := buf.String()
+
+	split := len(html)
+	for _, placeholder := range []string{inertiaPlaceholder, inertiaHeadPlaceholder} {
+		if idx := strings.Index(html, placeholder); idx >= 0 && idx < split {
+			split = idx
+		}
+	}
+
+	setHTMLResponse(ctx)
+	o.writeResponse(ctx)
+
+	// The request context must not be used inside the stream writer,
+	// it is called after the handler has returned.
+	logger := i.logger
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		if _, err := w.WriteString(html[:split]); err != nil {
+			return
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		inertia, inertiaHead, err := renderInertiaHTML()
+		if err != nil {
+			logger.Printf("cannot build inertia html: %s", err)
+		}
 
-	tmpl := template.New("").Funcs(template.FuncMap(i.sharedTemplateFuncs))
-	return tmpl.Parse(i.rootTemplateHTML)
+		replacer := strings.NewReplacer(
+			inertiaPlaceholder, string(inertia),
+			inertiaHeadPlaceholder, string(inertiaHead),
+		)
+		if _, err = replacer.WriteString(w, html[split:]); err != nil {
+			logger.Printf("cannot write streamed html response: %s", err)
+		}
+	})
+
+	return nil
 }
 
-func (i *Inertia) buildTemplateData(ctx *fasthttp.RequestCtx, page *page) (TemplateData, error) {
-	inertia, inertiaHead, err := i.buildInertiaHTML(page)
-	if err != nil {
-		return nil, fmt.Errorf("build inertia html: %w", err)
+// streamPlaceholderNonce returns a random nonce, so that template data cannot contain the placeholders.
+func streamPlaceholderNonce() (string, error) {
+	bs := make([]byte, 8)
+	if _, err := rand.Read(bs); err != nil {
+		return "", fmt.Errorf("generate placeholder nonce: %w", err)
 	}
+	return hex.EncodeToString(bs), nil
+}
+
+func (i *Inertia) buildTemplateData(ctx *fasthttp.RequestCtx, inertia, inertiaHead template.HTML, o *renderOptions) TemplateData {
 	templateData := TemplateData{
 		"inertia":     inertia,
 		"inertiaHead": inertiaHead,
@@ -545,28 +1074,62 @@ func (i *Inertia) buildTemplateData(ctx *fasthttp.RequestCtx, page *page) (Templ
 		templateData[key] = val
 	}
 
-	return templateData, nil
+	for key, val := range o.templateData {
+		templateData[key] = val
+	}
+
+	return templateData
 }
 
-func (i *Inertia) buildInertiaHTML(page *page) (inertia, inertiaHead template.HTML, _ error) {
+// inertiaHTMLFunc renders the Inertia container and head of the page.
+// It does not use the request context, so it can be called from the body stream writer.
+type inertiaHTMLFunc func() (inertia, inertiaHead template.HTML, _ error)
+
+// prepareInertiaHTML marshals the page and decides about SSR for the request,
+// and returns the func that renders the page, with SSR if it is enabled.
+func (i *Inertia) prepareInertiaHTML(ctx *fasthttp.RequestCtx, page *page, o *renderOptions) (inertiaHTMLFunc, error) {
 	pageJSON, err := i.jsonMarshaller.Marshal(page)
 	if err != nil {
-		return "", "", fmt.Errorf("json marshal page: %w", err)
+		return nil, fmt.Errorf("json marshal page: %w", err)
+	}
+
+	if !i.shouldSSR(ctx, page.Component, o) {
+		return func() (template.HTML, template.HTML, error) {
+			return i.htmlContainer(pageJSON)
+		}, nil
 	}
 
-	if i.isSSREnabled() {
-		inertia, inertiaHead, err = i.htmlContainerSSR(pageJSON)
+	var cacheKey string
+	if i.ssrCache != nil {
+		cacheKey = i.ssrCacheKeyFunc(ctx, page.Component, pageJSON)
+		if cacheKey != "" {
+			if inertia, inertiaHead, ok := i.ssrCache.get(cacheKey); ok {
+				return func() (template.HTML, template.HTML, error) {
+					return inertia, inertiaHead, nil
+				}, nil
+			}
+		}
+	}
+
+	component := page.Component
+	return func() (template.HTML, template.HTML, error) {
+		inertia, inertiaHead, err := i.renderSSR(component, pageJSON)
 		if err == nil {
+			if cacheKey != "" {
+				i.ssrCache.set(cacheKey, inertia, inertiaHead)
+			}
 			return inertia, inertiaHead, nil
 		}
-		i.logger.Printf("ssr rendering error: %s", err)
-	}
+		if !errors.Is(err, errSSRUnhealthy) {
+			i.logger.Printf("ssr rendering error: %s", err)
+		}
 
-	return i.htmlContainer(pageJSON)
+		return i.htmlContainer(pageJSON)
+	}, nil
 }
 
 func (i *Inertia) isSSREnabled() bool {
-	return i.ssrURL != ""
+	return i.ssrEndpoint != nil
 }
 
 // htmlContainerSSR will send request with json marshaled page payload to ssr render endpoint.
@@ -583,7 +1146,7 @@ func (i *Inertia) htmlContainerSSR(pageJSON []byte) (inertia, inertiaHead templa
 	req.Header.SetContentType("application/json")
 	req.SetBody(pageJSON)
 
-	if err := i.ssrHTTPClient.Do(req, resp); err != nil {
+	if err := i.ssrHTTPClient.DoTimeout(req, resp, i.ssrTimeout); err != nil {
 		return "", "", fmt.Errorf("execute http request: %w", err)
 	}
 
@@ -607,7 +1170,7 @@ func (i *Inertia) htmlContainerSSR(pageJSON []byte) (inertia, inertiaHead templa
 }
 
 func (i *Inertia) prepareSSRURL() string {
-	return strings.ReplaceAll(i.ssrURL, "/render", "") + "/render"
+	return i.ssrEndpoint.url("/render")
 }
 
 func (i *Inertia) htmlContainer(pageJSON []byte) (inertia, _ template.HTML, _ error) {
@@ -0,0 +1,357 @@
+package fibernetia
+
+import (
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+// renderTestPage renders the Inertia response of the component and returns the decoded page.
+func renderTestPage(t *testing.T, props Props, headers map[string]string) page {
+	t.Helper()
+
+	i, err := New("<html>{{ .inertia }}</html>")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI("/users")
+	ctx.Request.Header.Set(headerInertia, "true")
+	for key, val := range headers {
+		ctx.Request.Header.Set(key, val)
+	}
+
+	if err = i.Render(ctx, "Users/Index", props); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var p page
+	if err = json.Unmarshal(ctx.Response.Body(), &p); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	return p
+}
+
+func TestMergePaginated(t *testing.T) {
+	t.Parallel()
+
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI("/users?page=2")
+
+	users := Paginate(ctx, []int{3, 4}, 10, WithPaginatePerPage(2))
+
+	p := renderTestPage(t, Props{
+		"users":  MergePaginated(users).MatchOn("data.id"),
+		"posts":  MergePaginated(users, "items").Prepend(),
+		"plain":  Merge([]int{1}),
+		"nested": DeepMerge(Props{"a": 1}),
+	}, nil)
+
+	if want := []string{"plain", "users.data"}; !slices.Equal(p.MergeProps, want) {
+		t.Fatalf("got merge props %v, want %v", p.MergeProps, want)
+	}
+	if want := []string{"posts.items"}; !slices.Equal(p.PrependProps, want) {
+		t.Fatalf("got prepend props %v, want %v", p.PrependProps, want)
+	}
+	if want := []string{"nested"}; !slices.Equal(p.DeepMergeProps, want) {
+		t.Fatalf("got deep merge props %v, want %v", p.DeepMergeProps, want)
+	}
+	if want := []string{"users.data.id"}; !slices.Equal(p.MatchPropsOn, want) {
+		t.Fatalf("got match props on %v, want %v", p.MatchPropsOn, want)
+	}
+}
+
+func TestNestedProps_DotPaths(t *testing.T) {
+	t.Parallel()
+
+	props := func() Props {
+		return Props{
+			"auth": Props{
+				"user":        "john",
+				"permissions": Optional(func() (any, error) { return []string{"edit"}, nil }),
+				"token":       Always("secret"),
+			},
+			"stats": Props{
+				"count": 1,
+				"heavy": Defer(func() (any, error) { return 42, nil }, "stats"),
+			},
+			"title": "Users",
+		}
+	}
+
+	partial := func(header, val string) map[string]string {
+		return map[string]string{
+			headerInertiaPartialComponent: "Users/Index",
+			header:                        val,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    string
+		// wantDeferred are the deferred props of the group "stats".
+		wantDeferred []string
+	}{
+		{
+			name:         "first load",
+			want:         `{"auth":{"token":"secret","user":"john"},"errors":{},"stats":{"count":1},"title":"Users"}`,
+			wantDeferred: []string{"stats.heavy"},
+		},
+		{
+			name:    "only nested deferred",
+			headers: partial(headerInertiaPartialData, "stats.heavy"),
+			want:    `{"auth":{"token":"secret"},"errors":{},"stats":{"heavy":42}}`,
+		},
+		{
+			name:    "only nested optional",
+			headers: partial(headerInertiaPartialData, "auth.permissions"),
+			want:    `{"auth":{"permissions":["edit"],"token":"secret"},"errors":{}}`,
+		},
+		{
+			name:    "only parent",
+			headers: partial(headerInertiaPartialData, "auth"),
+			want:    `{"auth":{"permissions":["edit"],"token":"secret","user":"john"},"errors":{}}`,
+		},
+		{
+			name:    "except nested",
+			headers: partial(headerInertiaPartialExcept, "stats.heavy,auth.user"),
+			want:    `{"auth":{"permissions":["edit"],"token":"secret"},"errors":{},"stats":{"count":1},"title":"Users"}`,
+		},
+		{
+			name:    "except nested always",
+			headers: partial(headerInertiaPartialExcept, "auth.token,stats,title,auth.permissions,auth.user"),
+			want:    `{"auth":{"token":"secret"},"errors":{}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := renderTestPage(t, props(), tt.headers)
+
+			got, err := json.Marshal(p.Props)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("got props %s, want %s", got, tt.want)
+			}
+
+			if deferred := p.DeferredProps["stats"]; !slices.Equal(deferred, tt.wantDeferred) {
+				t.Fatalf("got deferred props %v, want %v", deferred, tt.wantDeferred)
+			}
+		})
+	}
+}
+
+func TestOnceProps(t *testing.T) {
+	t.Parallel()
+
+	expiresAt := time.UnixMilli(1700000000000)
+
+	props := func() Props {
+		return Props{
+			"plans":     Once([]string{"free", "pro"}).As("billing.plans").Until(expiresAt),
+			"countries": Once([]string{"NL"}),
+			"title":     "Users",
+		}
+	}
+
+	partial := func(headers map[string]string) map[string]string {
+		headers[headerInertiaPartialComponent] = "Users/Index"
+		return headers
+	}
+
+	tests := []struct {
+		name     string
+		headers  map[string]string
+		want     string
+		wantOnce string
+	}{
+		{
+			name:     "first visit",
+			want:     `{"countries":["NL"],"errors":{},"plans":["free","pro"],"title":"Users"}`,
+			wantOnce: `{"billing.plans":{"prop":"plans","expiresAt":1700000000000},"countries":{"prop":"countries","expiresAt":null}}`,
+		},
+		{
+			name:     "loaded once props are skipped",
+			headers:  map[string]string{headerInertiaExceptOnceProps: "billing.plans,countries"},
+			want:     `{"errors":{},"title":"Users"}`,
+			wantOnce: `{"billing.plans":{"prop":"plans","expiresAt":1700000000000},"countries":{"prop":"countries","expiresAt":null}}`,
+		},
+		{
+			name:     "partial reload of a loaded once prop",
+			headers:  partial(map[string]string{headerInertiaPartialData: "plans", headerInertiaExceptOnceProps: "billing.plans"}),
+			want:     `{"errors":{},"plans":["free","pro"]}`,
+			wantOnce: `{"billing.plans":{"prop":"plans","expiresAt":1700000000000}}`,
+		},
+		{
+			name:     "partial reload without once props",
+			headers:  partial(map[string]string{headerInertiaPartialData: "title"}),
+			want:     `{"errors":{},"title":"Users"}`,
+			wantOnce: `null`,
+		},
+		{
+			name:     "partial reload except once prop",
+			headers:  partial(map[string]string{headerInertiaPartialExcept: "plans"}),
+			want:     `{"countries":["NL"],"errors":{},"title":"Users"}`,
+			wantOnce: `{"countries":{"prop":"countries","expiresAt":null}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := renderTestPage(t, props(), tt.headers)
+
+			got, err := json.Marshal(p.Props)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("got props %s, want %s", got, tt.want)
+			}
+
+			gotOnce, err := json.Marshal(p.OnceProps)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if string(gotOnce) != tt.wantOnce {
+				t.Fatalf("got once props %s, want %s", gotOnce, tt.wantOnce)
+			}
+		})
+	}
+}
+
+func TestFlash_PartialReload(t *testing.T) {
+	t.Parallel()
+
+	flash, err := NewMemoryFlashProvider()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	i, err := New("<html>{{ .inertia }}</html>", WithFlashProvider(flash))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	post := &fasthttp.RequestCtx{}
+	post.Request.Header.SetMethod(fasthttp.MethodPost)
+	i.Flash(post, "toast", "saved")
+	i.Redirect(post, "/users")
+
+	// The partial reload does not request the flash prop, but the read messages are sent anyway.
+	get := nextStorageFlashRequest(post)
+	get.Request.SetRequestURI("/users")
+	get.Request.Header.Set(headerInertia, "true")
+	get.Request.Header.Set(headerInertiaPartialComponent, "Users/Index")
+	get.Request.Header.Set(headerInertiaPartialData, "title")
+
+	// Messages flashed by the rendering request are sent as well.
+	i.Flash(get, "notice", "hello")
+
+	if err = i.Render(get, "Users/Index", Props{"title": "Users", "users": []int{1}}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var p page
+	if err = json.Unmarshal(get.Response.Body(), &p); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	got, err := json.Marshal(p.Props)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want := `{"errors":{},"flash":{"notice":"hello","toast":"saved"},"title":"Users"}`; string(got) != want {
+		t.Fatalf("got props %s, want %s", got, want)
+	}
+}
+
+func TestHTMLStreaming_Placeholders(t *testing.T) {
+	t.Parallel()
+
+	const rootTemplate = `<html><head><script nonce="{{ .cspNonce }}"></script>{{ .inertiaHead }}</head>` +
+		`<body>{{ .inertia }}<script nonce="{{ .cspNonce }}"></script></body></html>`
+
+	tests := []struct {
+		name string
+		ssr  bool
+		want []string
+	}{
+		{
+			name: "without ssr",
+			want: []string{`<div id="app" data-page="`},
+		},
+		{
+			name: "with ssr",
+			ssr:  true,
+			want: []string{`<title>Users</title>`, `<div id="app">SSR</div>`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := []Option{WithHTMLStreaming()}
+			if tt.ssr {
+				ln := fasthttputil.NewInmemoryListener()
+				defer ln.Close()
+
+				go func() {
+					_ = fasthttp.Serve(ln, func(ctx *fasthttp.RequestCtx) {
+						ctx.SetContentType("application/json")
+						ctx.SetBodyString(`{"head": ["<title>Users</title>"], "body": "<div id=\"app\">SSR</div>"}`)
+					})
+				}()
+
+				client := &fasthttp.Client{Dial: func(string) (net.Conn, error) { return ln.Dial() }}
+				opts = append(opts, WithSSR(), WithSSRHTTPClient(client))
+			}
+
+			i, err := New(rootTemplate, opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.SetRequestURI("/users")
+
+			err = i.RenderWithOptions(ctx, "Users/Index", Props{"title": "Users"},
+				WithRenderTemplateDatum("cspNonce", "r4nd0m"))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if !ctx.Response.IsBodyStream() {
+				t.Fatal("response body is not streamed")
+			}
+
+			body := string(ctx.Response.Body())
+			if strings.Count(body, `nonce="r4nd0m"`) != 2 {
+				t.Fatalf("got body %s, want the csp nonce", body)
+			}
+			if strings.Contains(body, "<!--inertia") {
+				t.Fatalf("got body %s, want placeholders replaced", body)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(body, want) {
+					t.Fatalf("got body %s, want %s", body, want)
+				}
+			}
+		})
+	}
+}
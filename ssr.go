@@ -0,0 +1,338 @@
+package fibernetia
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	defaultSSRURL                 = "http://127.0.0.1:13714"
+	defaultSSRTimeout             = 5 * time.Second
+	defaultSSRFailureThreshold    = 3
+	defaultSSRCooldown            = 10 * time.Second
+	defaultSSRHealthCheckInterval = 5 * time.Second
+)
+
+// SSRRenderInfo describes a single SSR render attempt.
+type SSRRenderInfo struct {
+	Component string
+	Duration  time.Duration
+	// Err is the render error, if any. Inertia falls back to client side rendering on errors.
+	Err error
+	// Skipped is true if SSR was not attempted, because the SSR server is unhealthy.
+	Skipped bool
+}
+
+// SSRRenderHook is called after every SSR render attempt, e.g. to collect metrics.
+type SSRRenderHook func(info SSRRenderInfo)
+
+// SSRHealthHook is called when the SSR server becomes healthy or unhealthy.
+// The error is the cause of the unhealthy state.
+type SSRHealthHook func(healthy bool, err error)
+
+// ssrCircuitBreaker skips SSR after consecutive failures.
+// After the cooldown, a single request is let through to probe the SSR server.
+type ssrCircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	open      bool
+	openUntil time.Time
+}
+
+func newSSRCircuitBreaker(threshold int, cooldown time.Duration) *ssrCircuitBreaker {
+	return &ssrCircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// allow returns true if a request to the SSR server may be made.
+func (b *ssrCircuitBreaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.open {
+		return true
+	}
+	if now.Before(b.openUntil) {
+		return false
+	}
+
+	// Half-open: let this request through. Others are not allowed and are rendered without SSR
+	// until the probe succeeds, or until the cooldown passes again.
+	b.openUntil = now.Add(b.cooldown)
+	return true
+}
+
+// success closes the breaker and returns true if it was open.
+func (b *ssrCircuitBreaker) success() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wasOpen := b.open
+	b.failures = 0
+	b.open = false
+
+	return wasOpen
+}
+
+// failure counts the failure and returns true if the breaker has been opened by it.
+func (b *ssrCircuitBreaker) failure(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.openUntil = now.Add(b.cooldown)
+
+	if b.open || b.failures < b.threshold {
+		return false
+	}
+
+	b.open = true
+	return true
+}
+
+// SSRFilter decides whether the component is rendered with SSR.
+type SSRFilter func(ctx *fasthttp.RequestCtx, component string) bool
+
+// shouldSSR returns true if the page should be rendered with SSR.
+// The render option and the request-level setting win over the component exclusions and the filter.
+func (i *Inertia) shouldSSR(ctx *fasthttp.RequestCtx, component string, o *renderOptions) bool {
+	if !i.isSSREnabled() {
+		return false
+	}
+
+	if i.ssrProcess != nil && !i.ssrProcess.Ready() {
+		return false
+	}
+
+	if o.ssr != nil {
+		return *o.ssr
+	}
+
+	if ssr, ok := SSRFromContext(ctx); ok {
+		return ssr
+	}
+
+	for _, pattern := range i.ssrExcludedComponents {
+		if matched, _ := path.Match(pattern, component); matched {
+			return false
+		}
+	}
+
+	if i.ssrFilter != nil {
+		return i.ssrFilter(ctx, component)
+	}
+
+	return true
+}
+
+// StartSSRHealthCheck starts checking the SSR server's /health endpoint in the background
+// until ctx is done. Failed checks are counted by the circuit breaker like failed renders,
+// so pages are rendered without SSR after the threshold of consecutive failures.
+// The interval is 5 seconds by default.
+func (i *Inertia) StartSSRHealthCheck(ctx context.Context, interval ...time.Duration) {
+	if !i.isSSREnabled() {
+		return
+	}
+
+	ticker := time.NewTicker(firstOr(interval, defaultSSRHealthCheckInterval))
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			i.checkSSRHealth()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (i *Inertia) checkSSRHealth() {
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.Header.SetMethod(fasthttp.MethodGet)
+	req.SetRequestURI(i.prepareSSRHealthURL())
+
+	err := i.ssrHTTPClient.DoTimeout(req, resp, i.ssrTimeout)
+	if err == nil && resp.StatusCode() != fasthttp.StatusOK {
+		err = fmt.Errorf("invalid response status code: %d", resp.StatusCode())
+	}
+
+	if err != nil {
+		if i.ssrBreaker.failure(time.Now()) {
+			i.onSSRHealthChange(false, fmt.Errorf("health check: %w", err))
+		}
+		return
+	}
+
+	if i.ssrBreaker.success() {
+		i.onSSRHealthChange(true, nil)
+	}
+}
+
+// errSSRUnhealthy is returned when SSR is skipped, because the SSR server is unhealthy.
+var errSSRUnhealthy = errors.New("ssr server is unhealthy")
+
+// renderSSR calls the SSR server through the circuit breaker and reports the attempt to the render hook.
+func (i *Inertia) renderSSR(component string, pageJSON []byte) (inertia, inertiaHead template.HTML, _ error) {
+	start := time.Now()
+	if !i.ssrBreaker.allow(start) {
+		i.onSSRRender(SSRRenderInfo{Component: component, Skipped: true})
+		return "", "", errSSRUnhealthy
+	}
+
+	inertia, inertiaHead, err := i.htmlContainerSSR(pageJSON)
+	i.onSSRRender(SSRRenderInfo{Component: component, Duration: time.Since(start), Err: err})
+
+	if err != nil {
+		if i.ssrBreaker.failure(time.Now()) {
+			i.onSSRHealthChange(false, err)
+		}
+		return "", "", err
+	}
+
+	if i.ssrBreaker.success() {
+		i.onSSRHealthChange(true, nil)
+	}
+
+	return inertia, inertiaHead, nil
+}
+
+func (i *Inertia) onSSRRender(info SSRRenderInfo) {
+	if i.ssrRenderHook != nil {
+		i.ssrRenderHook(info)
+	}
+}
+
+func (i *Inertia) onSSRHealthChange(healthy bool, err error) {
+	if healthy {
+		i.logger.Println("ssr server is healthy, server side rendering is resumed")
+	} else {
+		i.logger.Printf("ssr server is unhealthy, server side rendering is skipped: %s", err)
+	}
+
+	if i.ssrHealthHook != nil {
+		i.ssrHealthHook(healthy, err)
+	}
+}
+
+// ssrUnixHost is the placeholder host of requests to an SSR server listening on a Unix socket.
+const ssrUnixHost = "unix"
+
+// ssrEndpoint is the parsed address of the SSR server.
+type ssrEndpoint struct {
+	// baseURL is the server URL without the trailing slash and "/render" endpoint.
+	baseURL string
+	// socketPath is the Unix socket path for "unix://" URLs.
+	socketPath string
+}
+
+// parseSSRURL parses the SSR server URL. It accepts http(s) URLs, with or without
+// the "/render" endpoint, and Unix socket URLs, e.g. "unix:///var/run/ssr.sock".
+func parseSSRURL(rawURL string) (*ssrEndpoint, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse ssr url: %w", err)
+	}
+
+	switch u.Scheme {
+	case "unix":
+		socketPath := u.Host + u.Path
+		if socketPath == "" {
+			return nil, fmt.Errorf("blank ssr unix socket path: %s", rawURL)
+		}
+		return &ssrEndpoint{
+			baseURL:    "http://" + ssrUnixHost,
+			socketPath: socketPath,
+		}, nil
+	case "http", "https":
+		if u.Host == "" {
+			return nil, fmt.Errorf("blank ssr url host: %s", rawURL)
+		}
+
+		u.Path = strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), "/render")
+		u.Path = strings.TrimSuffix(u.Path, "/")
+		u.RawPath = ""
+		u.RawQuery = ""
+		u.Fragment = ""
+
+		return &ssrEndpoint{baseURL: u.String()}, nil
+	default:
+		return nil, fmt.Errorf("unsupported ssr url scheme %q: %s", u.Scheme, rawURL)
+	}
+}
+
+// url returns the URL of the SSR server endpoint, e.g. "/render".
+func (e *ssrEndpoint) url(endpoint string) string {
+	return e.baseURL + endpoint
+}
+
+// client returns the client that dials the Unix socket with the timeout. The passed client is returned as is,
+// unless the endpoint is a Unix socket and the client has no custom dialer. In that case, a copy of the client
+// is returned, so that a client passed with WithSSRHTTPClient is not modified.
+func (e *ssrEndpoint) client(client *fasthttp.Client, timeout time.Duration) *fasthttp.Client {
+	if e.socketPath == "" || client == nil || client.Dial != nil {
+		return client
+	}
+
+	c := copySSRHTTPClient(client)
+	c.Dial = func(string) (net.Conn, error) {
+		return net.DialTimeout("unix", e.socketPath, timeout)
+	}
+
+	return c
+}
+
+// copySSRHTTPClient returns a new client with the settings of the client.
+// The client cannot be copied by value, because it holds its connections and locks.
+func copySSRHTTPClient(client *fasthttp.Client) *fasthttp.Client {
+	return &fasthttp.Client{
+		Name:                          client.Name,
+		NoDefaultUserAgentHeader:      client.NoDefaultUserAgentHeader,
+		Dial:                          client.Dial,
+		DialDualStack:                 client.DialDualStack,
+		TLSConfig:                     client.TLSConfig,
+		MaxConnsPerHost:               client.MaxConnsPerHost,
+		MaxIdleConnDuration:           client.MaxIdleConnDuration,
+		MaxConnDuration:               client.MaxConnDuration,
+		MaxIdemponentCallAttempts:     client.MaxIdemponentCallAttempts,
+		ReadBufferSize:                client.ReadBufferSize,
+		WriteBufferSize:               client.WriteBufferSize,
+		ReadTimeout:                   client.ReadTimeout,
+		WriteTimeout:                  client.WriteTimeout,
+		MaxResponseBodySize:           client.MaxResponseBodySize,
+		DisableHeaderNamesNormalizing: client.DisableHeaderNamesNormalizing,
+		DisablePathNormalizing:        client.DisablePathNormalizing,
+		MaxConnWaitTimeout:            client.MaxConnWaitTimeout,
+		RetryIf:                       client.RetryIf,
+		ConnPoolStrategy:              client.ConnPoolStrategy,
+		StreamResponseBody:            client.StreamResponseBody,
+		ConfigureClient:               client.ConfigureClient,
+	}
+}
+
+func (i *Inertia) prepareSSRHealthURL() string {
+	return i.ssrEndpoint.url("/health")
+}
@@ -0,0 +1,97 @@
+package fibernetia
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"html/template"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// SSRCacheKeyFunc returns the SSR cache key of the page.
+// If the key is empty, the page is not cached.
+type SSRCacheKeyFunc func(ctx *fasthttp.RequestCtx, component string, pageJSON []byte) string
+
+// defaultSSRCacheKey returns the hash of the marshalled page.
+func defaultSSRCacheKey(_ *fasthttp.RequestCtx, _ string, pageJSON []byte) string {
+	hash := sha256.Sum256(pageJSON)
+	return hex.EncodeToString(hash[:])
+}
+
+// ssrCache is a size-bounded LRU cache of SSR results with TTL.
+type ssrCache struct {
+	size int
+	ttl  time.Duration
+
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type ssrCacheEntry struct {
+	key         string
+	inertia     template.HTML
+	inertiaHead template.HTML
+	expiresAt   time.Time
+}
+
+func newSSRCache(size int, ttl time.Duration) *ssrCache {
+	return &ssrCache{
+		size:  size,
+		ttl:   ttl,
+		ll:    list.New(),
+		items: make(map[string]*list.Element, size),
+	}
+}
+
+func (c *ssrCache) get(key string) (inertia, inertiaHead template.HTML, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return "", "", false
+	}
+
+	entry := el.Value.(*ssrCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.remove(el)
+		return "", "", false
+	}
+
+	c.ll.MoveToFront(el)
+	return entry.inertia, entry.inertiaHead, true
+}
+
+func (c *ssrCache) set(key string, inertia, inertiaHead template.HTML) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(c.ttl)
+
+	if el, ok := c.items[key]; ok {
+		entry := el.Value.(*ssrCacheEntry)
+		entry.inertia, entry.inertiaHead, entry.expiresAt = inertia, inertiaHead, expiresAt
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&ssrCacheEntry{
+		key:         key,
+		inertia:     inertia,
+		inertiaHead: inertiaHead,
+		expiresAt:   expiresAt,
+	})
+
+	for c.ll.Len() > c.size {
+		c.remove(c.ll.Back())
+	}
+}
+
+func (c *ssrCache) remove(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*ssrCacheEntry).key)
+}
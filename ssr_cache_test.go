@@ -0,0 +1,63 @@
+package fibernetia
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSSRCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+
+	c := newSSRCache(2, time.Minute)
+	c.set("a", "A", "a-head")
+	c.set("b", "B", "b-head")
+
+	// Reading "a" makes "b" the least recently used entry.
+	if inertia, inertiaHead, ok := c.get("a"); !ok || inertia != "A" || inertiaHead != "a-head" {
+		t.Fatalf("got %q, %q, %v, want cached a", inertia, inertiaHead, ok)
+	}
+
+	c.set("c", "C", "c-head")
+
+	if _, _, ok := c.get("b"); ok {
+		t.Fatal("least recently used entry is not evicted")
+	}
+	if _, _, ok := c.get("a"); !ok {
+		t.Fatal("recently used entry is evicted")
+	}
+	if _, _, ok := c.get("c"); !ok {
+		t.Fatal("new entry is not cached")
+	}
+
+	// Updating an existing key does not grow the cache.
+	c.set("a", "A2", "a-head")
+	if inertia, _, _ := c.get("a"); inertia != "A2" {
+		t.Fatalf("got %q, want updated entry", inertia)
+	}
+	if c.ll.Len() != 2 || len(c.items) != 2 {
+		t.Fatalf("got %d entries, want 2", c.ll.Len())
+	}
+}
+
+func TestSSRCache_ExpiresAfterTTL(t *testing.T) {
+	t.Parallel()
+
+	c := newSSRCache(2, time.Minute)
+	c.set("a", "A", "")
+
+	// Move the entry past its TTL.
+	c.items["a"].Value.(*ssrCacheEntry).expiresAt = time.Now().Add(-time.Second)
+
+	if _, _, ok := c.get("a"); ok {
+		t.Fatal("expired entry is returned")
+	}
+	if _, ok := c.items["a"]; ok || c.ll.Len() != 0 {
+		t.Fatal("expired entry is not removed")
+	}
+
+	// Setting the key again refreshes its TTL.
+	c.set("a", "A", "")
+	if _, _, ok := c.get("a"); !ok {
+		t.Fatal("refreshed entry is not cached")
+	}
+}
@@ -0,0 +1,359 @@
+package fibernetia
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	defaultSSRProcessReadyTimeout = 30 * time.Second
+	defaultSSRProcessMinBackoff   = time.Second
+	defaultSSRProcessMaxBackoff   = 30 * time.Second
+	defaultSSRProcessStopTimeout  = 5 * time.Second
+	ssrProcessReadyPollInterval   = 100 * time.Millisecond
+	ssrProcessHealthTimeout       = time.Second
+)
+
+// SSRProcess spawns the SSR bundle (e.g. "node bootstrap/ssr/ssr.mjs") as a child process
+// and supervises it: the process is restarted with backoff when it exits,
+// its stdout and stderr are forwarded to the logger, and it is stopped when the context is done.
+// Unless WithSSRProcessLogger is used, the logger of the Inertia it is passed to is used.
+//
+// Pass it to WithSSRProcess, so that SSR is only used while the process is ready.
+type SSRProcess struct {
+	command      []string
+	dir          string
+	env          []string
+	endpoint     *ssrEndpoint
+	logger       Logger
+	loggerSet    bool
+	httpClient   *fasthttp.Client
+	readyTimeout time.Duration
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	stopTimeout  time.Duration
+
+	mu        sync.RWMutex
+	ready     bool
+	readyCh   chan struct{}
+	readyOnce sync.Once
+	done      chan struct{}
+	startOnce sync.Once
+}
+
+// SSRProcessOption is an option parameter that modifies SSRProcess.
+type SSRProcessOption func(p *SSRProcess) error
+
+// NewSSRProcess returns SSRProcess that runs the command, for example:
+//
+//	fibernetia.NewSSRProcess([]string{"node", "bootstrap/ssr/ssr.mjs"})
+func NewSSRProcess(command []string, opts ...SSRProcessOption) (*SSRProcess, error) {
+	if len(command) == 0 || command[0] == "" {
+		return nil, fmt.Errorf("blank ssr process command")
+	}
+
+	endpoint, err := parseSSRURL(defaultSSRURL)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &SSRProcess{
+		command:      command,
+		endpoint:     endpoint,
+		logger:       log.New(io.Discard, "", 0),
+		httpClient:   &fasthttp.Client{},
+		readyTimeout: defaultSSRProcessReadyTimeout,
+		minBackoff:   defaultSSRProcessMinBackoff,
+		maxBackoff:   defaultSSRProcessMaxBackoff,
+		stopTimeout:  defaultSSRProcessStopTimeout,
+		readyCh:      make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		if err := opt(p); err != nil {
+			return nil, fmt.Errorf("initialize ssr process: %w", err)
+		}
+	}
+
+	p.httpClient = p.endpoint.client(p.httpClient, ssrProcessHealthTimeout)
+
+	return p, nil
+}
+
+// WithSSRProcessURL returns SSRProcessOption that will set the URL the SSR server listens on.
+// It is used for readiness checks and by WithSSRProcess. Default is http://127.0.0.1:13714,
+// Unix socket URLs (e.g. unix:///var/run/ssr.sock) are supported as well.
+func WithSSRProcessURL(url string) SSRProcessOption {
+	return func(p *SSRProcess) error {
+		endpoint, err := parseSSRURL(url)
+		if err != nil {
+			return err
+		}
+		p.endpoint = endpoint
+		return nil
+	}
+}
+
+// WithSSRProcessDir returns SSRProcessOption that will set the working directory of the process.
+func WithSSRProcessDir(dir string) SSRProcessOption {
+	return func(p *SSRProcess) error {
+		p.dir = dir
+		return nil
+	}
+}
+
+// WithSSRProcessEnv returns SSRProcessOption that will add "KEY=value" environment variables to the process.
+func WithSSRProcessEnv(env ...string) SSRProcessOption {
+	return func(p *SSRProcess) error {
+		p.env = append(p.env, env...)
+		return nil
+	}
+}
+
+// WithSSRProcessLogger returns SSRProcessOption that will set the logger of the process output and lifecycle.
+func WithSSRProcessLogger(logger Logger) SSRProcessOption {
+	return func(p *SSRProcess) error {
+		if logger == nil {
+			logger = log.New(io.Discard, "", 0)
+		}
+		p.logger = logger
+		p.loggerSet = true
+		return nil
+	}
+}
+
+// WithSSRProcessReadyTimeout returns SSRProcessOption that will set how long Start waits for the process to become ready.
+func WithSSRProcessReadyTimeout(timeout time.Duration) SSRProcessOption {
+	return func(p *SSRProcess) error {
+		if timeout <= 0 {
+			return fmt.Errorf("invalid ssr process ready timeout: %s", timeout)
+		}
+		p.readyTimeout = timeout
+		return nil
+	}
+}
+
+// WithSSRProcessBackoff returns SSRProcessOption that will set the restart backoff.
+// The delay starts at min and doubles after every crash up to max.
+func WithSSRProcessBackoff(min, max time.Duration) SSRProcessOption {
+	return func(p *SSRProcess) error {
+		if min <= 0 || max < min {
+			return fmt.Errorf("invalid ssr process backoff: %s-%s", min, max)
+		}
+		p.minBackoff, p.maxBackoff = min, max
+		return nil
+	}
+}
+
+// WithSSRProcessStopTimeout returns SSRProcessOption that will set how long the process
+// may take to exit after the interrupt signal, before it is killed.
+func WithSSRProcessStopTimeout(timeout time.Duration) SSRProcessOption {
+	return func(p *SSRProcess) error {
+		if timeout <= 0 {
+			return fmt.Errorf("invalid ssr process stop timeout: %s", timeout)
+		}
+		p.stopTimeout = timeout
+		return nil
+	}
+}
+
+// Start starts and supervises the process until ctx is done.
+// It blocks until the SSR server answers its health endpoint, or the ready timeout passes.
+// The process keeps being supervised even if an error is returned.
+func (p *SSRProcess) Start(ctx context.Context) error {
+	p.startOnce.Do(func() {
+		go p.supervise(ctx)
+	})
+
+	timer := time.NewTimer(p.readyTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-p.readyCh:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return fmt.Errorf("ssr process is not ready after %s", p.readyTimeout)
+	}
+}
+
+// Ready returns true if the process is running and its SSR server is healthy.
+func (p *SSRProcess) Ready() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.ready
+}
+
+// Done returns a channel that is closed after the process has been stopped.
+func (p *SSRProcess) Done() <-chan struct{} {
+	return p.done
+}
+
+// log returns the logger of the process output and lifecycle.
+func (p *SSRProcess) log() Logger {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.logger
+}
+
+// useLogger sets the logger, unless it has been set with WithSSRProcessLogger.
+func (p *SSRProcess) useLogger(logger Logger) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.loggerSet {
+		p.logger = logger
+	}
+}
+
+func (p *SSRProcess) setReady(ready bool) {
+	p.mu.Lock()
+	p.ready = ready
+	p.mu.Unlock()
+
+	if ready {
+		p.readyOnce.Do(func() { close(p.readyCh) })
+	}
+}
+
+func (p *SSRProcess) supervise(ctx context.Context) {
+	defer close(p.done)
+
+	backoff := p.minBackoff
+	for {
+		startedAt := time.Now()
+		err := p.run(ctx)
+		p.setReady(false)
+
+		if ctx.Err() != nil {
+			p.log().Println("ssr process stopped")
+			return
+		}
+
+		// A process that has been running for a while is not crash looping.
+		if time.Since(startedAt) > p.maxBackoff {
+			backoff = p.minBackoff
+		}
+
+		p.log().Printf("ssr process exited: %v, restarting in %s", err, backoff)
+
+		select {
+		case <-ctx.Done():
+			p.log().Println("ssr process stopped")
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, p.maxBackoff)
+	}
+}
+
+// run runs the process once and waits for it to exit.
+func (p *SSRProcess) run(ctx context.Context) error {
+	cmd := exec.CommandContext(ctx, p.command[0], p.command[1:]...)
+	cmd.Dir = p.dir
+	cmd.Env = append(os.Environ(), p.env...)
+	cmd.Stdout = &ssrProcessLogWriter{logger: p.log(), prefix: "ssr: "}
+	cmd.Stderr = &ssrProcessLogWriter{logger: p.log(), prefix: "ssr stderr: "}
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = p.stopTimeout
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start: %w", err)
+	}
+	p.log().Printf("ssr process started with pid %d", cmd.Process.Pid)
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go p.waitReady(runCtx)
+
+	err := cmd.Wait()
+
+	cmd.Stdout.(*ssrProcessLogWriter).flush()
+	cmd.Stderr.(*ssrProcessLogWriter).flush()
+
+	return err
+}
+
+// waitReady polls the health endpoint until it succeeds or ctx is done.
+func (p *SSRProcess) waitReady(ctx context.Context) {
+	ticker := time.NewTicker(ssrProcessReadyPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if p.checkHealth() {
+			p.log().Println("ssr process is ready")
+			p.setReady(true)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *SSRProcess) checkHealth() bool {
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.Header.SetMethod(fasthttp.MethodGet)
+	req.SetRequestURI(p.endpoint.url("/health"))
+
+	err := p.httpClient.DoTimeout(req, resp, ssrProcessHealthTimeout)
+	return err == nil && resp.StatusCode() == fasthttp.StatusOK
+}
+
+// ssrProcessLogWriter forwards the process output to the logger line by line.
+type ssrProcessLogWriter struct {
+	logger Logger
+	prefix string
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *ssrProcessLogWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf.Write(p)
+	for {
+		line, err := w.buf.ReadBytes('\n')
+		if err != nil {
+			// Keep the incomplete line until the rest of it is written.
+			w.buf.Write(line)
+			return len(p), nil
+		}
+		w.logger.Printf("%s%s", w.prefix, bytes.TrimRight(line, "\r\n"))
+	}
+}
+
+func (w *ssrProcessLogWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.buf.Len() > 0 {
+		w.logger.Printf("%s%s", w.prefix, w.buf.Bytes())
+		w.buf.Reset()
+	}
+}
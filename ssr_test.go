@@ -0,0 +1,187 @@
+package fibernetia
+
+import (
+	"io"
+	"log"
+	"net"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+func TestSSRCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	const cooldown = time.Minute
+
+	now := time.Now()
+	b := newSSRCircuitBreaker(3, cooldown)
+
+	// Closed: failures below the threshold keep the breaker closed.
+	for range 2 {
+		if b.failure(now) {
+			t.Fatal("breaker opened before the threshold")
+		}
+		if !b.allow(now) {
+			t.Fatal("closed breaker does not allow requests")
+		}
+	}
+
+	// Open: the failure at the threshold opens the breaker.
+	if !b.failure(now) {
+		t.Fatal("breaker is not opened at the threshold")
+	}
+	if b.allow(now.Add(cooldown - time.Second)) {
+		t.Fatal("open breaker allows requests before the cooldown")
+	}
+
+	// Half-open: a single probe is let through after the cooldown.
+	now = now.Add(cooldown)
+	if !b.allow(now) {
+		t.Fatal("breaker does not allow the probe after the cooldown")
+	}
+	if b.allow(now) {
+		t.Fatal("breaker allows a second request while probing")
+	}
+
+	// Failed probe keeps the breaker open for another cooldown.
+	if b.failure(now) {
+		t.Fatal("failed probe reports the breaker as newly opened")
+	}
+	if b.allow(now.Add(cooldown - time.Second)) {
+		t.Fatal("breaker allows requests after a failed probe")
+	}
+
+	// Successful probe closes the breaker.
+	now = now.Add(cooldown)
+	if !b.allow(now) {
+		t.Fatal("breaker does not allow the probe after the cooldown")
+	}
+	if !b.success() {
+		t.Fatal("success does not report the breaker as closed")
+	}
+	if !b.allow(now) {
+		t.Fatal("closed breaker does not allow requests")
+	}
+
+	// Failures are counted from zero again after closing.
+	if b.failure(now) {
+		t.Fatal("breaker opened by the first failure after closing")
+	}
+	if b.success() {
+		t.Fatal("success reports a closed breaker as open")
+	}
+}
+
+func TestCheckSSRHealth_Threshold(t *testing.T) {
+	t.Parallel()
+
+	ln := fasthttputil.NewInmemoryListener()
+	defer ln.Close()
+
+	healthy := atomic.Bool{}
+	go func() {
+		_ = fasthttp.Serve(ln, func(ctx *fasthttp.RequestCtx) {
+			if !healthy.Load() {
+				ctx.SetStatusCode(fasthttp.StatusServiceUnavailable)
+			}
+		})
+	}()
+
+	client := &fasthttp.Client{Dial: func(string) (net.Conn, error) { return ln.Dial() }}
+
+	var changes []bool
+	i, err := New("<html></html>",
+		WithSSR(),
+		WithSSRHTTPClient(client),
+		WithSSRCircuitBreaker(2, time.Minute),
+		WithSSRHealthHook(func(healthy bool, _ error) { changes = append(changes, healthy) }),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// A single failed probe does not open the breaker below the threshold.
+	i.checkSSRHealth()
+	if !i.ssrBreaker.allow(time.Now()) || len(changes) != 0 {
+		t.Fatalf("got health changes %v after one failure, want none", changes)
+	}
+
+	i.checkSSRHealth()
+	if i.ssrBreaker.allow(time.Now()) || !slices.Equal(changes, []bool{false}) {
+		t.Fatalf("got health changes %v at the threshold, want unhealthy", changes)
+	}
+
+	healthy.Store(true)
+	i.checkSSRHealth()
+	if !i.ssrBreaker.allow(time.Now()) || !slices.Equal(changes, []bool{false, true}) {
+		t.Fatalf("got health changes %v after recovery, want healthy", changes)
+	}
+}
+
+func TestWithSSRProcess_Logger(t *testing.T) {
+	t.Parallel()
+
+	logger := log.New(io.Discard, "", 0)
+
+	process, err := NewSSRProcess([]string{"node", "ssr.mjs"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Inertia's logger is used, even if it is set after the process.
+	if _, err = New("<html></html>", WithSSRProcess(process), WithLogger(logger)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if process.log() != logger {
+		t.Fatal("ssr process does not use Inertia's logger")
+	}
+
+	processLogger := log.New(io.Discard, "", 0)
+
+	process, err = NewSSRProcess([]string{"node", "ssr.mjs"}, WithSSRProcessLogger(processLogger))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err = New("<html></html>", WithLogger(logger), WithSSRProcess(process)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if process.log() != processLogger {
+		t.Fatal("ssr process logger is replaced by Inertia's logger")
+	}
+}
+
+func TestWithSSRHTTPClient_UnixSocket(t *testing.T) {
+	t.Parallel()
+
+	client := &fasthttp.Client{ReadTimeout: time.Second}
+
+	i, err := New("<html></html>", WithSSR("unix:///tmp/ssr.sock"), WithSSRHTTPClient(client))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if client.Dial != nil {
+		t.Fatal("passed client is modified")
+	}
+	if i.ssrHTTPClient == client || i.ssrHTTPClient.Dial == nil {
+		t.Fatal("ssr client does not dial the socket")
+	}
+	if i.ssrHTTPClient.ReadTimeout != time.Second {
+		t.Fatal("ssr client does not keep the settings of the passed client")
+	}
+
+	// Clients with a custom dialer are used as is.
+	client.Dial = fasthttp.Dial
+
+	if i, err = New("<html></html>", WithSSR("unix:///tmp/ssr.sock"), WithSSRHTTPClient(client)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if i.ssrHTTPClient != client {
+		t.Fatal("client with a custom dialer is replaced")
+	}
+}
@@ -0,0 +1,218 @@
+package fibernetia
+
+import (
+	"fmt"
+	"html/template"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// rootTemplate is the root template of Inertia pages. It is parsed on first use and
+// parsed again after the shared template funcs have changed. In reload mode, the source file
+// is re-read at most once per interval, and the template is parsed again when it has changed.
+type rootTemplate struct {
+	// prebuilt is true for templates passed to NewFromTemplate, which cannot be parsed again.
+	prebuilt bool
+
+	// path and readFile are the source file of the template, if it has been read from a file.
+	path     string
+	readFile func() ([]byte, error)
+
+	mu        sync.RWMutex
+	html      string
+	tmpl      *template.Template
+	checkedAt time.Time
+}
+
+func newRootTemplate(html string) *rootTemplate {
+	return &rootTemplate{html: html}
+}
+
+func newPrebuiltRootTemplate(tmpl *template.Template) *rootTemplate {
+	return &rootTemplate{prebuilt: true, tmpl: tmpl}
+}
+
+func newRootTemplateFromFile(path string, readFile func() ([]byte, error)) (*rootTemplate, error) {
+	bs, err := readFile()
+	if err != nil {
+		return nil, fmt.Errorf("read file %q: %w", path, err)
+	}
+	if len(bs) == 0 {
+		return nil, fmt.Errorf("blank root template %q", path)
+	}
+
+	return &rootTemplate{
+		html:     string(bs),
+		path:     path,
+		readFile: readFile,
+	}, nil
+}
+
+// get returns the parsed template, parsing it with the funcs if needed.
+// A positive reload interval enables reloading of templates read from files.
+func (t *rootTemplate) get(funcs func() template.FuncMap, logger Logger, reloadInterval time.Duration) (*template.Template, error) {
+	if reloadInterval > 0 && t.readFile != nil {
+		t.reload(time.Now(), reloadInterval, logger)
+	}
+
+	t.mu.RLock()
+	tmpl := t.tmpl
+	t.mu.RUnlock()
+
+	if tmpl != nil {
+		return tmpl, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.tmpl != nil {
+		return t.tmpl, nil
+	}
+
+	tmpl, err := template.New("").Funcs(funcs()).Parse(t.html)
+	if err != nil {
+		return nil, err
+	}
+	t.tmpl = tmpl
+
+	return tmpl, nil
+}
+
+// invalidate makes the template to be parsed again on next use.
+func (t *rootTemplate) invalidate() {
+	if t.prebuilt {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.tmpl = nil
+}
+
+// reload re-reads the source file if the interval has passed since the last check.
+// The check is made under the read lock, so renders between checks run concurrently.
+// On error the previous template is kept.
+func (t *rootTemplate) reload(now time.Time, interval time.Duration, logger Logger) {
+	t.mu.RLock()
+	due := now.Sub(t.checkedAt) >= interval
+	t.mu.RUnlock()
+
+	if !due {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Another render may have reloaded the template, while the lock was released.
+	if now.Sub(t.checkedAt) < interval {
+		return
+	}
+	t.checkedAt = now
+
+	bs, err := t.readFile()
+	if err != nil {
+		logger.Printf("reload root template %q: %s", t.path, err)
+		return
+	}
+
+	if html := string(bs); html != t.html {
+		t.html = html
+		t.tmpl = nil
+	}
+}
+
+// withRootTemplateFile returns Option that will remember the source file of the root template for reloading.
+func withRootTemplateFile(path string, readFile func() ([]byte, error)) Option {
+	return func(i *Inertia) error {
+		i.rootTemplate.path = path
+		i.rootTemplate.readFile = readFile
+		return nil
+	}
+}
+
+// rootTemplateRule selects the named root template for components matching the pattern.
+type rootTemplateRule struct {
+	pattern string
+	name    string
+}
+
+// getRootTemplate returns the root template of the page. The template of the render option or the context
+// wins over the component rules, the default root template is used if none selects a template.
+func (i *Inertia) getRootTemplate(ctx *fasthttp.RequestCtx, component string, o *renderOptions) (*template.Template, error) {
+	name, ok := RootTemplateFromContext(ctx)
+	if o.rootTemplate != nil {
+		name, ok = *o.rootTemplate, true
+	}
+	if !ok {
+		for _, rule := range i.rootTemplateRules {
+			if matched, _ := path.Match(rule.pattern, component); matched {
+				name = rule.name
+				break
+			}
+		}
+	}
+
+	t := i.rootTemplate
+	if name != "" {
+		if t, ok = i.rootTemplates[name]; !ok {
+			return nil, fmt.Errorf("undefined root template %q", name)
+		}
+	}
+
+	return t.get(i.sharedTemplateFuncMap, i.logger, i.rootTemplateReloadInterval)
+}
+
+// validateRootTemplates checks that the rules select defined root templates,
+// and that reloading is enabled only if some root template is read from a file.
+func (i *Inertia) validateRootTemplates() error {
+	for _, rule := range i.rootTemplateRules {
+		if _, ok := i.rootTemplates[rule.name]; !ok && rule.name != "" {
+			return fmt.Errorf("root template rule %q: undefined root template %q", rule.pattern, rule.name)
+		}
+	}
+
+	if i.rootTemplateReloadInterval > 0 && !i.hasRootTemplateFile() {
+		return fmt.Errorf("root template reloading requires a root template read from a file")
+	}
+
+	return nil
+}
+
+// hasRootTemplateFile returns true if any root template is read from a file.
+func (i *Inertia) hasRootTemplateFile() bool {
+	if i.rootTemplate.readFile != nil {
+		return true
+	}
+	for _, t := range i.rootTemplates {
+		if t.readFile != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// invalidateRootTemplates makes all root templates to be parsed again on next use.
+func (i *Inertia) invalidateRootTemplates() {
+	i.rootTemplate.invalidate()
+	for _, t := range i.rootTemplates {
+		t.invalidate()
+	}
+}
+
+func (i *Inertia) sharedTemplateFuncMap() template.FuncMap {
+	i.sharedTemplateFuncsMu.RLock()
+	defer i.sharedTemplateFuncsMu.RUnlock()
+
+	funcs := make(template.FuncMap, len(i.sharedTemplateFuncs))
+	for key, val := range i.sharedTemplateFuncs {
+		funcs[key] = val
+	}
+
+	return funcs
+}
@@ -0,0 +1,109 @@
+package fibernetia
+
+import (
+	"html/template"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestRootTemplateRule_UndefinedName(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New("<html></html>", WithRootTemplateRule("Admin/*", "admin")); err == nil {
+		t.Fatal("expected error for undefined root template")
+	}
+
+	// Rules may be added before the templates they select.
+	_, err := New("<html></html>",
+		WithRootTemplateRule("Admin/*", "admin"),
+		WithRootTemplate("admin", "<html>admin</html>"),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestWithRootTemplateReload_RequiresFile(t *testing.T) {
+	t.Parallel()
+
+	if _, err := New("<html></html>", WithRootTemplateReload()); err == nil {
+		t.Fatal("expected error for root template not read from a file")
+	}
+
+	rootFS := fstest.MapFS{
+		"app.html":   {Data: []byte("<html></html>")},
+		"admin.html": {Data: []byte("<html>admin</html>")},
+	}
+
+	if _, err := NewFromFileFS(rootFS, "app.html", WithRootTemplateReload()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	_, err := New("<html></html>",
+		WithRootTemplateReload(),
+		WithRootTemplateFromFileFS("admin", rootFS, "admin.html"),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestRootTemplate_Reload(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	reads := 0
+	html := "<html>v1</html>"
+
+	rt, err := newRootTemplateFromFile("app.html", func() ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		reads++
+		return []byte(html), nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	logger := log.New(io.Discard, "", 0)
+	funcs := func() template.FuncMap { return nil }
+
+	// Concurrent renders within the interval check the file once.
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := rt.get(funcs, logger, time.Hour); err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	mu.Lock()
+	html = "<html>v2</html>"
+	if reads != 2 {
+		t.Errorf("got %d reads, want the initial read and a single check", reads)
+	}
+	mu.Unlock()
+
+	// The changed file is parsed again, once the check is due.
+	rt.reload(time.Now().Add(time.Hour), time.Hour, logger)
+
+	tmpl, err := rt.get(funcs, logger, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var sb strings.Builder
+	if err = tmpl.Execute(&sb, nil); err != nil || sb.String() != "<html>v2</html>" {
+		t.Fatalf("got %q, %v, want the reloaded template", sb.String(), err)
+	}
+}
@@ -0,0 +1,90 @@
+package fibernetia
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+// VersionFunc returns the current assets version of the request.
+type VersionFunc func(ctx *fasthttp.RequestCtx) string
+
+func (i *Inertia) resolveVersion(ctx *fasthttp.RequestCtx) string {
+	if i.versionFunc != nil {
+		return i.versionFunc(ctx)
+	}
+	return i.version
+}
+
+// fileVersionWatcher calculates the version based on the file checksum
+// and re-calculates it when the file modification time or size changes.
+// The file is checked on demand, at most once per interval.
+type fileVersionWatcher struct {
+	path     string
+	interval time.Duration
+	logger   func() Logger
+
+	mu        sync.Mutex
+	version   string
+	modTime   time.Time
+	size      int64
+	checkedAt time.Time
+}
+
+func newFileVersionWatcher(path string, interval time.Duration, logger func() Logger) (*fileVersionWatcher, error) {
+	w := &fileVersionWatcher{
+		path:     path,
+		interval: interval,
+		logger:   logger,
+	}
+
+	if err := w.refresh(time.Now()); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (w *fileVersionWatcher) Version(_ *fasthttp.RequestCtx) string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(w.checkedAt) < w.interval {
+		return w.version
+	}
+
+	if err := w.refresh(now); err != nil {
+		w.logger().Printf("watch version file %q: %s", w.path, err)
+	}
+
+	return w.version
+}
+
+// refresh re-calculates the version if the file has changed since the last check.
+// On error the previous version is kept.
+func (w *fileVersionWatcher) refresh(now time.Time) error {
+	w.checkedAt = now
+
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return err
+	}
+
+	if w.version != "" && info.ModTime().Equal(w.modTime) && info.Size() == w.size {
+		return nil
+	}
+
+	version, err := md5File(w.path)
+	if err != nil {
+		return err
+	}
+
+	w.version = version
+	w.modTime = info.ModTime()
+	w.size = info.Size()
+
+	return nil
+}
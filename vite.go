@@ -0,0 +1,301 @@
+package fibernetia
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// Vite renders asset tags for the root template from the Vite manifest.
+// If the hot file exists, tags point to the Vite dev server instead.
+type Vite struct {
+	rootFS        fs.FS
+	manifestPaths []string
+	hotFilePath   string
+	buildURL      string
+
+	manifestMu      sync.Mutex
+	manifest        map[string]viteChunk
+	manifestPath    string
+	manifestModTime time.Time
+	manifestSize    int64
+}
+
+// viteChunk is a manifest entry, see https://vite.dev/guide/backend-integration.
+type viteChunk struct {
+	File    string   `json:"file"`
+	Src     string   `json:"src"`
+	IsEntry bool     `json:"isEntry"`
+	Imports []string `json:"imports"`
+	CSS     []string `json:"css"`
+}
+
+// ViteOption is an option parameter that modifies Vite.
+type ViteOption func(v *Vite) error
+
+// NewVite returns Vite that reads the manifest and the hot file from rootFS,
+// which is usually the public directory (e.g. os.DirFS("public") or an embed.FS).
+//
+// By default, the manifest is read from "build/.vite/manifest.json" or "build/manifest.json",
+// the hot file from "hot", and built assets are served from "/build/".
+// The manifest is read again when its modification time or size changes, so rebuilt assets
+// are picked up without a restart.
+func NewVite(rootFS fs.FS, opts ...ViteOption) (*Vite, error) {
+	if rootFS == nil {
+		return nil, fmt.Errorf("nil vite root fs")
+	}
+
+	v := &Vite{
+		rootFS:        rootFS,
+		manifestPaths: []string{"build/.vite/manifest.json", "build/manifest.json"},
+		hotFilePath:   "hot",
+		buildURL:      "/build/",
+	}
+
+	for _, opt := range opts {
+		if err := opt(v); err != nil {
+			return nil, fmt.Errorf("initialize vite: %w", err)
+		}
+	}
+
+	return v, nil
+}
+
+// WithViteManifestPath returns ViteOption that will set the manifest path in the root fs.
+func WithViteManifestPath(path string) ViteOption {
+	return func(v *Vite) error {
+		v.manifestPaths = []string{path}
+		return nil
+	}
+}
+
+// WithViteHotFilePath returns ViteOption that will set the hot file path in the root fs.
+func WithViteHotFilePath(path string) ViteOption {
+	return func(v *Vite) error {
+		v.hotFilePath = path
+		return nil
+	}
+}
+
+// WithViteBuildURL returns ViteOption that will set the URL prefix of built assets.
+func WithViteBuildURL(url string) ViteOption {
+	return func(v *Vite) error {
+		v.buildURL = strings.TrimSuffix(url, "/") + "/"
+		return nil
+	}
+}
+
+// Register adds the "vite" and "viteReactRefresh" funcs to Inertia's shared template funcs:
+//
+//	{{ viteReactRefresh }}
+//	{{ vite "resources/js/app.tsx" "resources/css/app.css" }}
+func (v *Vite) Register(i *Inertia) error {
+	if err := i.ShareTemplateFunc("vite", v.Tags); err != nil {
+		return fmt.Errorf("share vite template func: %w", err)
+	}
+	if err := i.ShareTemplateFunc("viteReactRefresh", v.ReactRefresh); err != nil {
+		return fmt.Errorf("share vite react refresh template func: %w", err)
+	}
+	return nil
+}
+
+// Tags returns the tags of the entries.
+//
+// With the dev server, they are the @vite/client script and the entries loaded from the dev server.
+// Otherwise, they are stylesheet links, modulepreload hints for imported chunks and module scripts
+// of the hashed files from the manifest.
+func (v *Vite) Tags(entries ...string) (template.HTML, error) {
+	hotURL, ok := v.hotURL()
+	if ok {
+		return v.devTags(hotURL, entries), nil
+	}
+
+	manifest, err := v.loadManifest()
+	if err != nil {
+		return "", err
+	}
+
+	return v.buildTags(manifest, entries)
+}
+
+// ReactRefresh returns the React refresh preamble when the dev server is running, and nothing otherwise.
+func (v *Vite) ReactRefresh() template.HTML {
+	hotURL, ok := v.hotURL()
+	if !ok {
+		return ""
+	}
+
+	return template.HTML(`<script type="module">
+	import RefreshRuntime from "` + template.JSEscapeString(hotURL+"/@react-refresh") + `"
+	RefreshRuntime.injectIntoGlobalHook(window)
+	window.$RefreshReg$ = () => {}
+	window.$RefreshSig$ = () => (type) => type
+	window.__vite_plugin_react_preamble_installed__ = true
+</script>`)
+}
+
+// hotURL returns the dev server URL from the hot file.
+func (v *Vite) hotURL() (string, bool) {
+	bs, err := fs.ReadFile(v.rootFS, v.hotFilePath)
+	if err != nil {
+		return "", false
+	}
+
+	url := strings.TrimSuffix(strings.TrimSpace(string(bs)), "/")
+	return url, url != ""
+}
+
+func (v *Vite) devTags(hotURL string, entries []string) template.HTML {
+	var sb strings.Builder
+
+	writeViteScript(&sb, hotURL+"/@vite/client")
+	for _, entry := range entries {
+		url := hotURL + "/" + strings.TrimPrefix(entry, "/")
+		if isViteCSS(entry) {
+			writeViteLink(&sb, "stylesheet", url)
+		} else {
+			writeViteScript(&sb, url)
+		}
+	}
+
+	return template.HTML(sb.String())
+}
+
+func (v *Vite) buildTags(manifest map[string]viteChunk, entries []string) (template.HTML, error) {
+	var styles, preloads, scripts strings.Builder
+
+	seenCSS := make(map[string]struct{})
+	seenChunks := make(map[string]struct{})
+
+	var walk func(name string, isEntry bool) error
+	walk = func(name string, isEntry bool) error {
+		if _, ok := seenChunks[name]; ok {
+			return nil
+		}
+		seenChunks[name] = struct{}{}
+
+		chunk, ok := manifest[name]
+		if !ok {
+			return fmt.Errorf("unable to locate file in vite manifest: %s", name)
+		}
+
+		for _, css := range chunk.CSS {
+			if _, ok := seenCSS[css]; !ok {
+				seenCSS[css] = struct{}{}
+				writeViteLink(&styles, "stylesheet", v.buildURL+css)
+			}
+		}
+
+		for _, imported := range chunk.Imports {
+			if err := walk(imported, false); err != nil {
+				return err
+			}
+		}
+
+		url := v.buildURL + chunk.File
+		switch {
+		case isViteCSS(chunk.File):
+			if _, ok := seenCSS[chunk.File]; !ok {
+				seenCSS[chunk.File] = struct{}{}
+				writeViteLink(&styles, "stylesheet", url)
+			}
+		case isEntry:
+			writeViteScript(&scripts, url)
+		default:
+			writeViteLink(&preloads, "modulepreload", url)
+		}
+
+		return nil
+	}
+
+	for _, entry := range entries {
+		if err := walk(strings.TrimPrefix(entry, "/"), true); err != nil {
+			return "", err
+		}
+	}
+
+	return template.HTML(styles.String() + preloads.String() + scripts.String()), nil
+}
+
+// loadManifest reads the manifest on first use, and reads it again when its modification time
+// or size changes, e.g. after a rebuild. If the changed manifest cannot be read (e.g. it is being
+// written), the previous one is used. Failed first reads are retried on the next call.
+func (v *Vite) loadManifest() (map[string]viteChunk, error) {
+	v.manifestMu.Lock()
+	defer v.manifestMu.Unlock()
+
+	if v.manifest != nil {
+		info, err := fs.Stat(v.rootFS, v.manifestPath)
+		if err == nil && info.ModTime().Equal(v.manifestModTime) && info.Size() == v.manifestSize {
+			return v.manifest, nil
+		}
+	}
+
+	if err := v.readManifest(); err != nil && v.manifest == nil {
+		return nil, err
+	}
+
+	return v.manifest, nil
+}
+
+// readManifest reads the first manifest found in the manifest paths.
+func (v *Vite) readManifest() error {
+	var errs []error
+	for _, manifestPath := range v.manifestPaths {
+		info, err := fs.Stat(v.rootFS, manifestPath)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("read vite manifest %q: %w", manifestPath, err))
+			continue
+		}
+
+		bs, err := fs.ReadFile(v.rootFS, manifestPath)
+		if err != nil {
+			return fmt.Errorf("read vite manifest %q: %w", manifestPath, err)
+		}
+
+		manifest := make(map[string]viteChunk)
+		if err = json.Unmarshal(bs, &manifest); err != nil {
+			return fmt.Errorf("json unmarshal vite manifest %q: %w", manifestPath, err)
+		}
+
+		v.manifest = manifest
+		v.manifestPath = manifestPath
+		v.manifestModTime = info.ModTime()
+		v.manifestSize = info.Size()
+		return nil
+	}
+
+	return errors.Join(errs...)
+}
+
+func isViteCSS(name string) bool {
+	switch path.Ext(name) {
+	case ".css", ".less", ".sass", ".scss", ".styl", ".stylus", ".pcss", ".postcss":
+		return true
+	default:
+		return false
+	}
+}
+
+func writeViteScript(sb *strings.Builder, url string) {
+	sb.WriteString(`<script type="module" src="`)
+	sb.WriteString(template.HTMLEscapeString(url))
+	sb.WriteString(`"></script>`)
+	sb.WriteString("\n")
+}
+
+func writeViteLink(sb *strings.Builder, rel, url string) {
+	sb.WriteString(`<link rel="`)
+	sb.WriteString(rel)
+	sb.WriteString(`" href="`)
+	sb.WriteString(template.HTMLEscapeString(url))
+	sb.WriteString(`">`)
+	sb.WriteString("\n")
+}
@@ -0,0 +1,176 @@
+package fibernetia
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+const testViteManifest = `{
+	"resources/js/app.tsx": {
+		"file": "assets/app-abc.js",
+		"src": "resources/js/app.tsx",
+		"isEntry": true,
+		"imports": ["_vendor-def.js"],
+		"css": ["assets/app-ghi.css"]
+	},
+	"_vendor-def.js": {
+		"file": "assets/vendor-def.js",
+		"imports": ["_shared-jkl.js"],
+		"css": ["assets/vendor-mno.css"]
+	},
+	"_shared-jkl.js": {
+		"file": "assets/shared-jkl.js",
+		"css": ["assets/app-ghi.css"]
+	},
+	"resources/css/app.css": {
+		"file": "assets/style-pqr.css",
+		"src": "resources/css/app.css",
+		"isEntry": true
+	}
+}`
+
+func TestVite_Tags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		entries []string
+		want    template.HTML
+		wantErr bool
+	}{
+		{
+			name:    "nested imports",
+			files:   fstest.MapFS{"build/.vite/manifest.json": {Data: []byte(testViteManifest)}},
+			entries: []string{"resources/js/app.tsx", "resources/css/app.css"},
+			want: `<link rel="stylesheet" href="/build/assets/app-ghi.css">
+<link rel="stylesheet" href="/build/assets/vendor-mno.css">
+<link rel="stylesheet" href="/build/assets/style-pqr.css">
+<link rel="modulepreload" href="/build/assets/shared-jkl.js">
+<link rel="modulepreload" href="/build/assets/vendor-def.js">
+<script type="module" src="/build/assets/app-abc.js"></script>
+`,
+		},
+		{
+			name:    "legacy manifest path",
+			files:   fstest.MapFS{"build/manifest.json": {Data: []byte(testViteManifest)}},
+			entries: []string{"resources/css/app.css"},
+			want: `<link rel="stylesheet" href="/build/assets/style-pqr.css">
+`,
+		},
+		{
+			name: "vite 5 manifest path first",
+			files: fstest.MapFS{
+				"build/.vite/manifest.json": {Data: []byte(`{"app.js": {"file": "assets/new.js", "isEntry": true}}`)},
+				"build/manifest.json":       {Data: []byte(`{"app.js": {"file": "assets/old.js", "isEntry": true}}`)},
+			},
+			entries: []string{"app.js"},
+			want: `<script type="module" src="/build/assets/new.js"></script>
+`,
+		},
+		{
+			name: "dev server",
+			files: fstest.MapFS{
+				"hot":                       {Data: []byte("http://localhost:5173/\n")},
+				"build/.vite/manifest.json": {Data: []byte(testViteManifest)},
+			},
+			entries: []string{"resources/js/app.tsx", "/resources/css/app.css"},
+			want: `<script type="module" src="http://localhost:5173/@vite/client"></script>
+<script type="module" src="http://localhost:5173/resources/js/app.tsx"></script>
+<link rel="stylesheet" href="http://localhost:5173/resources/css/app.css">
+`,
+		},
+		{
+			name:    "unknown entry",
+			files:   fstest.MapFS{"build/.vite/manifest.json": {Data: []byte(testViteManifest)}},
+			entries: []string{"resources/js/missing.tsx"},
+			wantErr: true,
+		},
+		{
+			name:    "missing manifest",
+			files:   fstest.MapFS{},
+			entries: []string{"resources/js/app.tsx"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			v, err := NewVite(tt.files)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			got, err := v.Tags(tt.entries...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("got tags:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVite_ReactRefresh(t *testing.T) {
+	t.Parallel()
+
+	v, err := NewVite(fstest.MapFS{"hot": {Data: []byte("http://localhost:5173")}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := v.ReactRefresh(); !strings.Contains(string(got), "http://localhost:5173/@react-refresh") {
+		t.Fatalf("got %s, want react refresh preamble", got)
+	}
+
+	if v, err = NewVite(fstest.MapFS{}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := v.ReactRefresh(); got != "" {
+		t.Fatalf("got %s, want nothing without the dev server", got)
+	}
+}
+
+func TestVite_ManifestReload(t *testing.T) {
+	t.Parallel()
+
+	const manifestPath = "build/.vite/manifest.json"
+
+	modTime := time.Now()
+	files := fstest.MapFS{manifestPath: {
+		Data:    []byte(`{"app.js": {"file": "assets/app-1.js", "isEntry": true}}`),
+		ModTime: modTime,
+	}}
+
+	v, err := NewVite(files)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if got, _ := v.Tags("app.js"); !strings.Contains(string(got), "assets/app-1.js") {
+		t.Fatalf("got %s, want the first build", got)
+	}
+
+	// A rebuild changes the manifest.
+	files[manifestPath] = &fstest.MapFile{
+		Data:    []byte(`{"app.js": {"file": "assets/app-2.js", "isEntry": true}}`),
+		ModTime: modTime.Add(time.Second),
+	}
+	if got, _ := v.Tags("app.js"); !strings.Contains(string(got), "assets/app-2.js") {
+		t.Fatalf("got %s, want the rebuilt assets", got)
+	}
+
+	// A manifest, which is being written, does not replace the previous one.
+	files[manifestPath] = &fstest.MapFile{
+		Data:    []byte(`{"app.js": `),
+		ModTime: modTime.Add(2 * time.Second),
+	}
+	if got, err := v.Tags("app.js"); err != nil || !strings.Contains(string(got), "assets/app-2.js") {
+		t.Fatalf("got %s, %v, want the previous build", got, err)
+	}
+}